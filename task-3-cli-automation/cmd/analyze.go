@@ -107,7 +107,7 @@ func init() {
 	// Dependency analysis flags
 	analyzeDepsCmd.Flags().BoolP("security", "s", false, "check security vulnerabilities")
 	analyzeDepsCmd.Flags().BoolP("outdated", "o", false, "check for outdated packages")
-	analyzeDepsCmd.Flags().BoolP("conflicts", "c", false, "check for version conflicts")
+	analyzeDepsCmd.Flags().BoolP("conflicts", "", false, "check for version conflicts")
 	
 	// Add subcommands
 	analyzeCmd.AddCommand(analyzeCodeCmd)
@@ -1,11 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/automation"
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -93,8 +99,10 @@ var workflowRunCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		name := args[0]
+		parallel, _ := cmd.Flags().GetInt("parallel")
 		
-		automationService := automation.NewAutomationService(".")
+		automationService := newAutomationService(cmd)
+		automationService.SetParallelism(parallel)
 		
 		// Load existing workflows
 		if err := automationService.LoadWorkflows(); err != nil {
@@ -102,51 +110,454 @@ var workflowRunCmd = &cobra.Command{
 			os.Exit(1)
 		}
 		
-		result, err := automationService.ExecuteWorkflow(name)
+		// Ctrl+C interrupts the running steps and skips the rest
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		
+		result, err := automationService.RunWorkflow(ctx, name, automation.RunOptions{})
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error executing workflow: %v\n", err)
 			os.Exit(1)
 		}
 		
-		fmt.Printf("\n📊 Workflow execution completed in %v\n", result.Duration)
+		printWorkflowResult(result)
 		
-		if result.Success {
-			fmt.Println("✅ All steps completed successfully")
-		} else {
-			fmt.Printf("❌ Workflow failed: %v\n", result.Error)
+		if !result.Success {
+			os.Exit(1)
+		}
+	},
+}
+
+var workflowWatchCmd = &cobra.Command{
+	Use:   "watch [name...]",
+	Short: "Run file_change workflows when project files change",
+	Long: `Watch the project for file changes and run every file_change workflow whose
+trigger pattern matches a changed file, or only the named workflows.
+
+Changes are collected until the project has been quiet for the debounce
+period, so a burst of saves runs each workflow once. The changed paths are
+passed to the workflow in $K3SS_CHANGED_FILES, one per line. Files ignored
+by .gitignore or .k3ss-aiignore are not watched.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		poll, _ := cmd.Flags().GetBool("poll")
+		interval, _ := cmd.Flags().GetDuration("interval")
+		debounce, _ := cmd.Flags().GetDuration("debounce")
+		parallel, _ := cmd.Flags().GetInt("parallel")
+		
+		automationService := newAutomationService(cmd)
+		automationService.SetParallelism(parallel)
+		
+		// Load existing workflows
+		if err := automationService.LoadWorkflows(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading workflows: %v\n", err)
+			os.Exit(1)
+		}
+		
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		
+		fmt.Println("👀 Watching for changes (Ctrl+C to stop)...")
+		err := automationService.Watch(ctx, automation.WatchOptions{
+			Workflows: args,
+			Poll:      poll,
+			Interval:  interval,
+			Debounce:  debounce,
+			OnResult: func(name string, result *automation.WorkflowResult, err error) {
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error executing workflow %s: %v\n", name, err)
+					return
+				}
+				printWorkflowResult(result)
+				fmt.Println("\n👀 Watching for changes...")
+			},
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error watching files: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var workflowTriggerCmd = &cobra.Command{
+	Use:   "trigger --event <hook> [-- hook args...]",
+	Short: "Run the git_hook workflows bound to a hook",
+	Long: `Run every git_hook workflow bound to a git hook. This is what the shims
+installed by "k3ss-ai hooks install" call; the hook's arguments follow --
+and its standard input, such as the refs given to pre-push, is read when
+it is not a terminal.
+
+The workflows receive the hook name in $K3SS_EVENT and its arguments in
+$K3SS_HOOK_ARGS, one per line. The command fails if any workflow fails,
+which makes pre-commit and pre-push hooks abort.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		event, _ := cmd.Flags().GetString("event")
+		if event == "" {
+			fmt.Fprintf(os.Stderr, "Error: --event is required\n")
+			os.Exit(1)
+		}
+		
+		var input []byte
+		if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice == 0 {
+			input, _ = io.ReadAll(os.Stdin)
+		}
+		
+		automationService := newAutomationService(cmd)
+		
+		// Load existing workflows
+		if err := automationService.LoadWorkflows(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading workflows: %v\n", err)
+			os.Exit(1)
+		}
+		
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		
+		failed := false
+		err := automationService.TriggerEvent(ctx, event, args, string(input), func(name string, result *automation.WorkflowResult, err error) {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error executing workflow %s: %v\n", name, err)
+				failed = true
+				return
+			}
+			printWorkflowResult(result)
+			failed = failed || !result.Success
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error triggering workflows: %v\n", err)
+			os.Exit(1)
+		}
+		
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+var workflowSchedulerCmd = &cobra.Command{
+	Use:   "scheduler",
+	Short: "Run schedule workflows at their cron times",
+	Long: `Run in the foreground, starting each workflow whose trigger is schedule
+when its cron expression (the trigger pattern) fires.
+
+Runs are recorded in .k3ss-ai/scheduler.json. A workflow that missed runs
+while the scheduler was stopped runs once on start, unless --skip-missed
+is given. Use --once to print the next fire times and exit.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		once, _ := cmd.Flags().GetBool("once")
+		count, _ := cmd.Flags().GetInt("count")
+		skipMissed, _ := cmd.Flags().GetBool("skip-missed")
+		parallel, _ := cmd.Flags().GetInt("parallel")
+		
+		automationService := newAutomationService(cmd)
+		automationService.SetParallelism(parallel)
+		
+		// Load existing workflows
+		if err := automationService.LoadWorkflows(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading workflows: %v\n", err)
+			os.Exit(1)
+		}
+		
+		if once {
+			scheduled, err := automationService.ScheduledWorkflows(time.Now())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading schedules: %v\n", err)
+				os.Exit(1)
+			}
+			if len(scheduled) == 0 {
+				fmt.Println("No schedule workflows found")
+				return
+			}
+			
+			for _, entry := range scheduled {
+				fmt.Printf("⏰ %s (%s)\n", entry.Workflow.Name, entry.Workflow.Trigger.Pattern)
+				if !entry.LastFire.IsZero() {
+					fmt.Printf("   Last run: %s\n", entry.LastFire.Format("2006-01-02 15:04 MST"))
+				}
+				if entry.Missed > 0 {
+					fmt.Printf("   Missed: %d run(s)\n", entry.Missed)
+				}
+				fire := time.Now()
+				for i := 0; i < count; i++ {
+					if fire = entry.Schedule.Next(fire); fire.IsZero() {
+						break
+					}
+					fmt.Printf("   Next: %s\n", fire.Format("2006-01-02 15:04 MST (Mon)"))
+				}
+			}
+			return
+		}
+		
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		
+		fmt.Println("⏰ Scheduler running (Ctrl+C to stop)...")
+		err := automationService.RunScheduler(ctx, automation.SchedulerOptions{
+			SkipMissed: skipMissed,
+			OnResult: func(name string, fire time.Time, result *automation.WorkflowResult, err error) {
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error executing workflow %s: %v\n", name, err)
+					return
+				}
+				fmt.Printf("Scheduled run for %s\n", fire.Format("2006-01-02 15:04"))
+				printWorkflowResult(result)
+			},
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error running scheduler: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var workflowHistoryCmd = &cobra.Command{
+	Use:   "history [name]",
+	Short: "Show past runs of a workflow",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		limit, _ := cmd.Flags().GetInt("limit")
+		
+		automationService := automation.NewAutomationService(".")
+		runs, err := automationService.RunHistory(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading run history: %v\n", err)
+			os.Exit(1)
+		}
+		
+		if len(runs) == 0 {
+			fmt.Printf("No recorded runs of workflow '%s'\n", name)
+			return
+		}
+		
+		fmt.Printf("Runs of %s:\n", name)
+		for i, run := range runs {
+			if limit > 0 && i == limit {
+				fmt.Printf("  ... %d older run(s)\n", len(runs)-limit)
+				break
+			}
+			fmt.Printf("  %s %s  %s  %-8s  %v  (%s)\n", runStatusIcon(run.Status), run.ID,
+				run.StartTime.Format("2006-01-02 15:04:05"), run.Status, run.Duration.Round(time.Millisecond), run.Trigger)
+			if run.Error != "" {
+				fmt.Printf("     Error: %s\n", run.Error)
+			}
+		}
+	},
+}
+
+var workflowLogsCmd = &cobra.Command{
+	Use:   "logs [run-id]",
+	Short: "Show the output of a workflow run",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		stepID, _ := cmd.Flags().GetString("step")
+		stream, _ := cmd.Flags().GetString("stream")
+		
+		automationService := automation.NewAutomationService(".")
+		run, err := automationService.FindRun(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
 		}
 		
-		// Show step results
-		fmt.Println("\nStep results:")
-		for i, step := range result.Steps {
-			status := "✅"
-			if !step.Success {
-				status = "❌"
+		found := false
+		for _, step := range run.Steps {
+			if stepID != "" && step.ID != stepID {
+				continue
+			}
+			found = true
+			
+			log, err := automationService.StepLog(run, step, stream)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
 			}
-			fmt.Printf("  %s Step %d: %s (%v)\n", status, i+1, step.StepName, step.Duration)
-			if step.Error != nil {
-				fmt.Printf("    Error: %v\n", step.Error)
+			if stepID != "" {
+				fmt.Print(log)
+				return
 			}
+			
+			fmt.Printf("%s [%s] %s (%s, %v)\n", runStatusIcon(step.Status), step.ID, step.Name, step.Status, step.Duration.Round(time.Millisecond))
+			if step.Error != "" {
+				fmt.Printf("Error: %s\n", step.Error)
+			}
+			printSandboxReport("", step.Sandbox)
+			if log != "" {
+				fmt.Print(log)
+				if !strings.HasSuffix(log, "\n") {
+					fmt.Println()
+				}
+			}
+			fmt.Println()
+		}
+		
+		if !found {
+			fmt.Fprintf(os.Stderr, "Error: run %s has no step '%s'\n", run.ID, stepID)
+			os.Exit(1)
 		}
 	},
 }
 
+// newAutomationService creates the automation service for the current
+// project, keeping run history as configured
+func newAutomationService(cmd *cobra.Command) *automation.AutomationService {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	
+	automationService := automation.NewAutomationService(".")
+	automationService.SetRetention(automation.Retention{
+		KeepRuns: cfg.Workflows.KeepRuns,
+		MaxAge:   time.Duration(cfg.Workflows.KeepDays) * 24 * time.Hour,
+	})
+	
+	// --sandbox turns the configured sandbox on for commands that offer it
+	if enabled, _ := cmd.Flags().GetBool("sandbox"); enabled || cfg.Sandbox.Enabled {
+		automationService.SetSandbox(newSandbox(cfg.Sandbox))
+	}
+	return automationService
+}
+
+// newBatchProcessor creates the batch processor for the current project,
+// with the configured linters and sandbox
+func newBatchProcessor(cmd *cobra.Command) *automation.BatchProcessor {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	
+	batchProcessor := automation.NewBatchProcessor(".")
+	if linters, err := config.ParseToolMap(cfg.Batch.Linters); err == nil {
+		batchProcessor.SetLinters(linters)
+	}
+	if enabled, _ := cmd.Flags().GetBool("sandbox"); enabled || cfg.Sandbox.Enabled {
+		batchProcessor.SetSandbox(newSandbox(cfg.Sandbox))
+	}
+	return batchProcessor
+}
+
+// newBatchProgressPrinter returns a callback drawing a batch's progress as
+// a bar redrawn in place, at most every 100ms until the last file
+func newBatchProgressPrinter() func(automation.BatchProgress) {
+	const width = 30
+	var lastDraw time.Time
+	return func(progress automation.BatchProgress) {
+		if progress.Done < progress.Total && time.Since(lastDraw) < 100*time.Millisecond {
+			return
+		}
+		lastDraw = time.Now()
+		
+		filled := width * progress.Done / progress.Total
+		eta := "--"
+		if progress.Done > 0 {
+			eta = progress.ETA().Round(time.Second).String()
+		}
+		fmt.Printf("\r[%s%s] %d/%d files, %d errors, ETA %s\033[K",
+			strings.Repeat("█", filled), strings.Repeat("░", width-filled),
+			progress.Done, progress.Total, progress.Errors, eta)
+	}
+}
+
+// newSandbox builds the step sandbox from its configuration
+func newSandbox(settings config.SandboxConfig) *automation.Sandbox {
+	return &automation.Sandbox{
+		CPUTime:       time.Duration(settings.CPUTime) * time.Second,
+		Memory:        uint64(settings.Memory) << 20,
+		OpenFiles:     uint64(settings.OpenFiles),
+		EnvAllowlist:  config.SplitList(settings.EnvAllowlist),
+		ReadOnlyPaths: config.SplitList(settings.ReadOnlyPaths),
+	}
+}
+
+// runStatusIcon returns the icon for a recorded run or step status
+func runStatusIcon(status string) string {
+	switch status {
+	case "success":
+		return "✅"
+	case "skipped":
+		return "⏭️ "
+	default:
+		return "❌"
+	}
+}
+
+// printWorkflowResult summarises a finished workflow run
+func printWorkflowResult(result *automation.WorkflowResult) {
+	if result.Skipped {
+		return
+	}
+	
+	fmt.Printf("\n📊 Workflow execution completed in %v (run %s)\n", result.Duration, result.RunID)
+	
+	if result.Success {
+		fmt.Println("✅ All steps completed successfully")
+	} else {
+		fmt.Printf("❌ Workflow failed: %v\n", result.Error)
+	}
+	
+	// Show step results
+	fmt.Println("\nStep results:")
+	for i, step := range result.Steps {
+		status := "✅"
+		timing := fmt.Sprintf("started +%v, took %v", step.StartTime.Sub(result.StartTime).Round(time.Millisecond), step.Duration)
+		switch {
+		case step.Skipped:
+			status = "⏭️ "
+			timing = "skipped"
+		case !step.Success:
+			status = "❌"
+		}
+		if len(step.Attempts) > 1 {
+			timing += fmt.Sprintf(", %d attempts", len(step.Attempts))
+		}
+		label := fmt.Sprintf("Step %d", i+1)
+		if step.Phase != "" {
+			label = step.Phase
+		}
+		fmt.Printf("  %s %s [%s]: %s (%s)\n", status, label, step.StepID, step.StepName, timing)
+		if step.Error != nil {
+			fmt.Printf("    Error: %v\n", step.Error)
+		}
+		printSandboxReport("    ", step.Sandbox)
+	}
+}
+
+// printSandboxReport shows the sandbox limits applied to a step and those
+// that could not be
+func printSandboxReport(indent string, report *automation.SandboxReport) {
+	if report == nil {
+		return
+	}
+	fmt.Printf("%s🔒 Sandbox: %s\n", indent, report)
+	for _, fallback := range report.Fallbacks {
+		fmt.Printf("%s⚠️  %s\n", indent, fallback)
+	}
+}
+
 var workflowInitCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize with prebuilt workflows",
+	Long: `Create prebuilt workflows built from the built-in templates, in the
+flavour of the project's build system (Go, Cargo, Maven or npm), found
+from its Cargo.toml, go.mod, pom.xml or package.json; npm is used when there
+is none. Use --build-system to pick another flavour.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		buildSystem, _ := cmd.Flags().GetString("build-system")
+		
 		automationService := automation.NewAutomationService(".")
 		
 		fmt.Println("🚀 Creating prebuilt workflows...")
 		
-		if err := automationService.CreatePrebuiltWorkflows(); err != nil {
+		workflows, err := automationService.CreatePrebuiltWorkflows(buildSystem)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating prebuilt workflows: %v\n", err)
 			os.Exit(1)
 		}
 		
 		fmt.Println("✅ Prebuilt workflows created:")
-		fmt.Println("  - deploy-staging: Deploy application to staging")
-		fmt.Println("  - quality-check: Run code quality and security checks")
+		for _, workflow := range workflows {
+			fmt.Printf("  - %s: %s\n", workflow.Name, workflow.Description)
+		}
 	},
 }
 
@@ -162,66 +573,173 @@ var batchRunCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		operation := args[0]
-		pattern, _ := cmd.Flags().GetString("pattern")
+		patterns, _ := cmd.Flags().GetStringArray("pattern")
+		changedSince, _ := cmd.Flags().GetString("changed-since")
 		recursive, _ := cmd.Flags().GetBool("recursive")
 		dryRun, _ := cmd.Flags().GetBool("dry-run")
 		exclude, _ := cmd.Flags().GetStringSlice("exclude")
+		jobs, _ := cmd.Flags().GetInt("jobs")
+		failFast, _ := cmd.Flags().GetBool("fail-fast")
+		apply, _ := cmd.Flags().GetBool("apply")
+		atomic, _ := cmd.Flags().GetBool("atomic")
 		
-		batchProcessor := automation.NewBatchProcessor(".")
+		batchProcessor := newBatchProcessor(cmd)
+		if (operation == "add-comments" || operation == "add-tests") && !dryRun {
+			aiClient, err := getAIClient(cmd)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating AI client: %v\n", err)
+				os.Exit(1)
+			}
+			batchProcessor.SetAIClient(aiClient)
+		}
 		
 		batchOp := &automation.BatchOperation{
-			Name:      fmt.Sprintf("batch-%s", operation),
-			Operation: operation,
-			Pattern:   pattern,
-			DryRun:    dryRun,
-			Recursive: recursive,
-			Exclude:   exclude,
+			Name:         fmt.Sprintf("batch-%s", operation),
+			Operation:    operation,
+			Patterns:     patterns,
+			ChangedSince: changedSince,
+			DryRun:       dryRun,
+			Apply:        apply,
+			Recursive:    recursive,
+			Exclude:      exclude,
+			Jobs:         jobs,
+			FailFast:     failFast,
+			Atomic:       atomic,
+		}
+		
+		// On a terminal, a progress bar replaces the per-file messages
+		progressBar := !dryRun && isTerminal(os.Stdout)
+		if progressBar {
+			batchProcessor.SetOutput(nil)
+			batchOp.Progress = newBatchProgressPrinter()
 		}
 		
 		fmt.Printf("🔄 Executing batch operation: %s\n", operation)
-		fmt.Printf("Pattern: %s\n", pattern)
+		fmt.Printf("Pattern: %s\n", strings.Join(patterns, " "))
+		if changedSince != "" {
+			fmt.Printf("Changed since: %s\n", changedSince)
+		}
 		
-		result, err := batchProcessor.ExecuteBatchOperation(batchOp)
+		// Interrupting stops the running tools and skips the remaining files
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		
+		result, err := batchProcessor.RunBatchOperation(ctx, batchOp)
+		if progressBar {
+			fmt.Println()
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error executing batch operation: %v\n", err)
 			os.Exit(1)
 		}
 		
-		fmt.Printf("\n📊 Batch operation completed\n")
+		fmt.Printf("\n📊 Batch operation completed in %v\n", result.Duration.Round(time.Millisecond))
 		fmt.Printf("Files found: %d\n", result.FilesFound)
 		fmt.Printf("Files processed: %d\n", result.FilesProcessed)
+		if result.FilesCancelled > 0 {
+			fmt.Printf("Files cancelled: %d\n", result.FilesCancelled)
+		}
 		
 		if len(result.Errors) > 0 {
 			fmt.Printf("Errors: %d\n", len(result.Errors))
+			missing := make(map[string]int)
+			var tools []string
 			for _, err := range result.Errors {
+				if err.MissingTool != "" {
+					if missing[err.MissingTool] == 0 {
+						tools = append(tools, err.MissingTool)
+					}
+					missing[err.MissingTool]++
+					continue
+				}
 				fmt.Printf("  ❌ %s: %s\n", err.File, err.Error)
+				for _, line := range strings.Split(strings.TrimRight(err.Output, "\n"), "\n") {
+					if line != "" {
+						fmt.Printf("     %s\n", line)
+					}
+				}
+			}
+			for _, tool := range tools {
+				fmt.Printf("  🔧 %s is not installed (needed for %d file(s))\n", tool, missing[tool])
 			}
 		}
 		
+		if pending, _ := batchProcessor.PendingPatches(); len(pending) > 0 && !dryRun {
+			fmt.Printf("📝 %d patch(es) to review in %s; run again with --apply to accept them\n", len(pending), automation.PatchDir)
+		}
+		
+		if len(result.RolledBack) > 0 {
+			fmt.Printf("↩️  Rolled back %d changed file(s); no files were changed\n", len(result.RolledBack))
+		} else if result.RunID != "" {
+			fmt.Printf("↩️  Undo with: k3ss-ai undo %s\n", result.RunID)
+		}
+		
 		if result.Success {
 			fmt.Println("✅ Batch operation completed successfully")
 		} else {
 			fmt.Println("⚠️  Batch operation completed with errors")
+			os.Exit(1)
 		}
 	},
 }
 
 func init() {
 	// Workflow create flags
-	workflowCreateCmd.Flags().StringP("description", "d", "", "workflow description")
+	workflowCreateCmd.Flags().StringP("description", "", "", "workflow description")
 	workflowCreateCmd.Flags().StringP("trigger", "t", "manual", "workflow trigger (manual, file_change, git_hook)")
 	workflowCreateCmd.Flags().StringSliceP("steps", "s", []string{}, "workflow steps (command with args)")
 	
+	// Workflow run flags
+	workflowRunCmd.Flags().IntP("parallel", "j", 0, "maximum number of steps to run at once (default: workflow setting, or one per CPU)")
+	workflowRunCmd.Flags().Bool("sandbox", false, "run steps in the sandbox configured under sandbox: (limits, environment allowlist, read-only paths)")
+	
+	// Workflow trigger flags
+	workflowTriggerCmd.Flags().String("event", "", "git hook that fired, e.g. pre-commit or pre-push")
+	workflowTriggerCmd.Flags().Bool("sandbox", false, "run steps in the sandbox configured under sandbox: (limits, environment allowlist, read-only paths)")
+	
+	// Workflow scheduler flags
+	workflowSchedulerCmd.Flags().Bool("once", false, "print the next fire times of each schedule workflow and exit")
+	workflowSchedulerCmd.Flags().Int("count", 5, "number of fire times to print with --once")
+	workflowSchedulerCmd.Flags().Bool("skip-missed", false, "do not run workflows that missed runs while the scheduler was stopped")
+	workflowSchedulerCmd.Flags().IntP("parallel", "j", 0, "maximum number of steps to run at once (default: workflow setting, or one per CPU)")
+	workflowSchedulerCmd.Flags().Bool("sandbox", false, "run steps in the sandbox configured under sandbox: (limits, environment allowlist, read-only paths)")
+	
+	// Workflow history and logs flags
+	workflowHistoryCmd.Flags().IntP("limit", "n", 20, "number of runs to show (0 shows all)")
+	workflowLogsCmd.Flags().String("step", "", "only print the log of this step id")
+	workflowLogsCmd.Flags().String("stream", "", "only print this stream of each step: stdout or stderr")
+	
+	// Workflow watch flags
+	workflowWatchCmd.Flags().Bool("poll", false, "poll for changes instead of using inotify")
+	workflowWatchCmd.Flags().Duration("interval", time.Second, "how often to scan for changes when polling")
+	workflowWatchCmd.Flags().Duration("debounce", 500*time.Millisecond, "wait until files stop changing for this long before running")
+	workflowWatchCmd.Flags().IntP("parallel", "j", 0, "maximum number of steps to run at once (default: workflow setting, or one per CPU)")
+	workflowWatchCmd.Flags().Bool("sandbox", false, "run steps in the sandbox configured under sandbox: (limits, environment allowlist, read-only paths)")
+	
+	// Workflow init flags
+	workflowInitCmd.Flags().String("build-system", "", "flavour of the workflows: "+strings.Join(automation.PrebuiltFlavours(), ", ")+" (default: detected)")
+	
 	// Batch operation flags
-	batchRunCmd.Flags().StringP("pattern", "p", "*", "file pattern to match")
+	batchRunCmd.Flags().StringArrayP("pattern", "p", []string{"*"}, "file pattern to match; repeatable, \"**\" matches any directories and \"!\" excludes")
+	batchRunCmd.Flags().String("changed-since", "", "only process files changed since this git ref")
 	batchRunCmd.Flags().BoolP("recursive", "r", false, "search recursively")
 	batchRunCmd.Flags().BoolP("dry-run", "", false, "show what would be done without executing")
-	batchRunCmd.Flags().StringSliceP("exclude", "e", []string{"node_modules", ".git"}, "patterns to exclude")
+	batchRunCmd.Flags().StringSliceP("exclude", "e", []string{"node_modules", ".git"}, "patterns to exclude, as in .gitignore (files ignored by .gitignore and .k3ss-aiignore are always skipped)")
+	batchRunCmd.Flags().IntP("jobs", "j", 0, "number of files to process at once (default: one per CPU)")
+	batchRunCmd.Flags().Bool("fail-fast", false, "cancel the remaining files after the first error")
+	batchRunCmd.Flags().Bool("atomic", false, "all or nothing: if any file fails, restore the files already changed (implies --fail-fast)")
+	batchRunCmd.Flags().Bool("apply", false, "apply generated patches, or those already waiting for review, instead of writing them to "+automation.PatchDir)
+	batchRunCmd.Flags().Bool("sandbox", false, "run tools in the sandbox configured under sandbox: (limits, environment allowlist, read-only paths)")
 	
 	// Add workflow subcommands
 	workflowCmd.AddCommand(workflowCreateCmd)
 	workflowCmd.AddCommand(workflowListCmd)
 	workflowCmd.AddCommand(workflowRunCmd)
+	workflowCmd.AddCommand(workflowWatchCmd)
+	workflowCmd.AddCommand(workflowTriggerCmd)
+	workflowCmd.AddCommand(workflowSchedulerCmd)
+	workflowCmd.AddCommand(workflowHistoryCmd)
+	workflowCmd.AddCommand(workflowLogsCmd)
 	workflowCmd.AddCommand(workflowInitCmd)
 	
 	// Add batch subcommands
@@ -24,6 +24,12 @@ var buildRunCmd = &cobra.Command{
 		analyze, _ := cmd.Flags().GetBool("analyze")
 		fix, _ := cmd.Flags().GetBool("fix")
 		
+		if !cmd.Flags().Changed("command") {
+			if cfg, err := loadConfig(cmd); err == nil && cfg.Build.Command != "" {
+				command = cfg.Build.Command
+			}
+		}
+		
 		buildService := build.NewBuildService(".", command)
 		
 		fmt.Printf("Running build command: %s\n", command)
@@ -175,7 +181,7 @@ var pipelineGenerateCmd = &cobra.Command{
 
 func init() {
 	// Build command flags
-	buildRunCmd.Flags().StringP("command", "c", "npm run build", "build command to execute")
+	buildRunCmd.Flags().StringP("command", "", "npm run build", "build command to execute")
 	buildRunCmd.Flags().BoolP("analyze", "a", true, "analyze build results")
 	buildRunCmd.Flags().BoolP("fix", "f", false, "attempt automatic fixes")
 	
@@ -1,8 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
 
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/ai"
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/chat"
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/git"
 	"github.com/spf13/cobra"
 )
 
@@ -14,33 +21,147 @@ var chatCmd = &cobra.Command{
 Examples:
   k3ss-ai chat "help me optimize this function"
   k3ss-ai chat --file main.go "explain this code"
-  k3ss-ai chat --interactive`,
+  k3ss-ai chat --interactive
+  k3ss-ai chat --resume 20250101-120000`,
 	Args: cobra.MinimumNArgs(0),
 	Run: func(cmd *cobra.Command, args []string) {
 		interactive, _ := cmd.Flags().GetBool("interactive")
 		file, _ := cmd.Flags().GetString("file")
+		extraContext, _ := cmd.Flags().GetString("context")
+		noStream, _ := cmd.Flags().GetBool("no-stream")
+		resume, _ := cmd.Flags().GetString("resume")
+		listSessions, _ := cmd.Flags().GetBool("sessions")
 		
-		if interactive {
-			fmt.Println("Starting interactive chat session...")
-			// TODO: Implement interactive chat
+		if listSessions {
+			sessions, err := chat.NewSessionStore(".").List()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error listing sessions: %v\n", err)
+				os.Exit(1)
+			}
+			if len(sessions) == 0 {
+				fmt.Println("No chat sessions found")
+				return
+			}
+			fmt.Println("Chat sessions:")
+			for _, session := range sessions {
+				fmt.Printf("  💬 %s - %d messages, last active %s\n", session.ID, len(session.Messages), session.Updated.Format("2006-01-02 15:04:05"))
+			}
+			return
+		}
+		
+		if interactive || resume != "" {
+			runInteractiveChat(cmd, resume, !noStream && isTerminal(os.Stdout))
 		} else if len(args) > 0 {
 			message := args[0]
+			if extraContext != "" {
+				message = fmt.Sprintf("%s\n\nAdditional context:\n%s", message, extraContext)
+			}
+			
+			client, err := getAIClient(cmd)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			
+			var files []ai.FileInfo
 			if file != "" {
-				fmt.Printf("Analyzing file %s with message: %s\n", file, message)
-			} else {
-				fmt.Printf("Processing message: %s\n", message)
+				fileInfo, err := ai.FileFromPath(file)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				files = append(files, fileInfo)
+			}
+			
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			
+			request := ai.NewRequest(ai.RequestChat, message)
+			request.AttachFiles(files...)
+			
+			if _, err := sendChatRequest(ctx, client, request, !noStream && isTerminal(os.Stdout)); err != nil {
+				if errors.Is(err, context.Canceled) {
+					fmt.Fprintln(os.Stderr, "\nCancelled")
+					os.Exit(130)
+				}
+				fmt.Fprintf(os.Stderr, "Error sending message: %v\n", err)
+				os.Exit(1)
 			}
-			// TODO: Send message to AI orchestration service
 		} else {
 			fmt.Println("Please provide a message or use --interactive flag")
 		}
 	},
 }
 
+// sendChatRequest sends a chat request, streaming tokens to stdout when stream is
+// set and printing the full answer once it arrives otherwise
+func sendChatRequest(ctx context.Context, client *ai.Client, request *ai.Request, stream bool) (*ai.Response, error) {
+	if !stream {
+		response, err := client.Send(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Println(response.Content)
+		return response, nil
+	}
+	
+	response, err := client.Stream(ctx, request, func(token string) error {
+		_, err := fmt.Print(token)
+		return err
+	})
+	fmt.Println()
+	return response, err
+}
+
+// runInteractiveChat starts the REPL, resuming a stored session when resume is set
+func runInteractiveChat(cmd *cobra.Command, resume string, stream bool) {
+	client, err := getAIClient(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	
+	store := chat.NewSessionStore(".")
+	session := chat.NewSession(client.Model())
+	if resume != "" {
+		session, err = store.Load(resume)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resuming session: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Resuming session %s with %d messages\n", session.ID, len(session.Messages))
+	}
+	
+	send := func(ctx context.Context, request *ai.Request) (*ai.Response, error) {
+		// Ctrl-C cancels the current response without leaving the session
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+		return sendChatRequest(ctx, client, request, stream)
+	}
+	
+	repl := chat.NewREPL(os.Stdin, os.Stdout, send, store, session, git.NewGitService("."))
+	if err := repl.Run(context.Background()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving session: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
 func init() {
 	chatCmd.Flags().BoolP("interactive", "i", false, "start interactive chat session")
 	chatCmd.Flags().StringP("file", "f", "", "analyze specific file")
 	chatCmd.Flags().StringP("context", "", "", "additional context for the conversation")
+	chatCmd.Flags().BoolP("no-stream", "", false, "wait for the full response instead of streaming tokens")
+	chatCmd.Flags().StringP("resume", "r", "", "resume a saved interactive session by ID")
+	chatCmd.Flags().BoolP("sessions", "", false, "list saved chat sessions")
 	rootCmd.AddCommand(chatCmd)
 }
 
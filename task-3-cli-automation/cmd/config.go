@@ -1,41 +1,164 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/config"
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/credentials"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "Manage K3SS AI configuration",
-	Long: `Configure K3SS AI settings including AI service endpoints, 
-authentication, and default behaviors.`,
+	Long: `Configure K3SS AI settings including AI service endpoints,
+authentication, and default behaviors.
+
+Settings are addressed by dotted keys such as ai.model or git.commit_style.
+
+Values are merged from, in increasing precedence: built-in defaults, the user
+file (~/.k3ss-ai.yaml or --config), the nearest .k3ss-ai/config.yaml above the
+current directory, K3SS_AI_* environment variables (e.g. K3SS_AI_AI_MODEL) and
+command-line flags. 'config set' and 'config unset' edit the user file.
+
+Named AI profiles live under profiles.<name> and override the ai block; the
+profile key (or --profile) selects one. routing.<command> entries pick a
+profile and model per command, e.g. routing.review.model or
+"routing.git commit.profile"; --profile bypasses routing.`,
 }
 
 var configShowCmd = &cobra.Command{
 	Use:   "show",
 	Short: "Show current configuration",
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Current K3SS AI Configuration:")
-		// TODO: Load and display current config
-		fmt.Println("AI Endpoint: http://localhost:8080")
-		fmt.Println("Model: gpt-4")
-		fmt.Println("Auto-commit: false")
-		fmt.Println("Output format: text")
+		format, _ := cmd.Flags().GetString("format")
+		showOrigin, _ := cmd.Flags().GetBool("origin")
+
+		cfg, origins, err := loadLayeredConfig(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		if showOrigin {
+			for _, key := range append(config.Keys(), config.EntryKeys(cfg)...) {
+				value, _ := config.GetValue(cfg, key)
+				if _, ok := origins[key]; !ok {
+					continue
+				}
+				fmt.Printf("%-28s = %-24s # %s\n", key, config.FormatValue(key, value), origins[key])
+			}
+			fmt.Println()
+			printRouting(cfg, origins, "")
+			return
+		}
+
+		redacted := config.Redacted(cfg)
+
+		switch format {
+		case "yaml":
+			data, err := yaml.Marshal(redacted)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error formatting configuration: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Print(string(data))
+			fmt.Println()
+			printRouting(cfg, origins, "# ")
+		case "json":
+			data, err := json.MarshalIndent(redacted, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error formatting configuration: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unsupported format %q (use yaml or json)\n", format)
+			os.Exit(1)
+		}
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "Get a configuration value",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		key := args[0]
+
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		value, err := config.GetValue(cfg, key)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Println(config.FormatValue(key, value))
 	},
 }
 
 var configSetCmd = &cobra.Command{
 	Use:   "set [key] [value]",
 	Short: "Set configuration value",
-	Args:  cobra.ExactArgs(2),
+	Long: `Set a value in the user configuration file.
+
+Secrets such as ai.api_key are never written to the file. The value is stored
+in the encrypted keystore (~/.k3ss-ai/keystore.json, unlocked by a passphrase
+or $K3SS_AI_KEYSTORE_PASSPHRASE) or, with --store file, in a 0600 file under
+~/.k3ss-ai/credentials, and the file records a reference to it. A reference
+can also be given directly: env:NAME, file:PATH or keystore:NAME.`,
+	Args: cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		key := args[0]
 		value := args[1]
-		
-		fmt.Printf("Setting %s = %s\n", key, value)
-		// TODO: Update configuration
+
+		if config.IsSecret(key) && !credentials.IsReference(value) {
+			provider, err := secretProvider(cmd, key)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := provider.Set(value); err != nil {
+				fmt.Fprintf(os.Stderr, "Error storing %s: %v\n", key, err)
+				os.Exit(1)
+			}
+			value = provider.Reference()
+			fmt.Printf("Stored %s with the %s provider\n", key, strings.SplitN(value, ":", 2)[0])
+		}
+
+		updateConfig(cmd, func(path string) error {
+			return config.SetFileValue(path, key, value)
+		})
+
+		fmt.Printf("Set %s = %s\n", key, config.FormatValue(key, value))
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset [key]",
+	Short: "Remove a configuration value so lower layers apply",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		key := args[0]
+
+		updateConfig(cmd, func(path string) error {
+			return config.UnsetFileValue(path, key)
+		})
+
+		fmt.Printf("Removed %s from the user configuration\n", key)
 	},
 }
 
@@ -43,17 +166,275 @@ var configInitCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize configuration with defaults",
 	Run: func(cmd *cobra.Command, args []string) {
+		force, _ := cmd.Flags().GetBool("force")
+
+		path, err := configFilePath(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if _, err := os.Stat(path); err == nil && !force {
+			fmt.Fprintf(os.Stderr, "Error: configuration already exists at %s (use --force to overwrite)\n", path)
+			os.Exit(1)
+		}
+
 		fmt.Println("Initializing K3SS AI configuration...")
-		// TODO: Create default config file
-		fmt.Println("Configuration initialized at ~/.k3ss-ai.yaml")
+		if err := config.SaveConfig(config.DefaultConfig(), path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing configuration: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Configuration initialized at %s\n", path)
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check every configuration layer for unknown keys and invalid values",
+	Long: `Validate the user file, the project file, K3SS_AI_* environment variables
+and flags. Every problem is reported with its source and line number, and the
+command exits with status 1 if any are found, so it can be used in CI.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		userPath, err := configFilePath(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		files := []string{userPath}
+		if cwd, err := os.Getwd(); err == nil {
+			if projectPath := config.FindProjectConfig(cwd); projectPath != "" {
+				files = append(files, projectPath)
+			}
+		}
+		for _, path := range files {
+			version, err := config.FileVersion(path)
+			if err == nil && version < config.CurrentVersion {
+				fmt.Fprintf(os.Stderr, "Warning: %s uses config version %d (current is %d); run 'k3ss-ai config migrate'\n",
+					path, version, config.CurrentVersion)
+			}
+		}
+
+		cfg, origins, err := loadLayeredConfig(cmd)
+		if err != nil {
+			var validationErr *config.ValidationError
+			if errors.As(err, &validationErr) {
+				for _, problem := range validationErr.Problems {
+					fmt.Fprintf(os.Stderr, "%s\n", problem)
+				}
+				fmt.Fprintf(os.Stderr, "Found %d configuration problem(s)\n", len(validationErr.Problems))
+			} else {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			os.Exit(1)
+		}
+
+		for _, key := range config.Keys() {
+			value, _ := config.GetValue(cfg, key)
+			layer := origins[key].Layer
+			if config.IsSecret(key) && (layer == config.LayerUser || layer == config.LayerProject) &&
+				!credentials.IsReference(fmt.Sprint(value)) {
+				fmt.Fprintf(os.Stderr, "Warning: %s is stored in plaintext in %s; run 'k3ss-ai config set %s <secret>' to move it to the keystore\n",
+					key, origins[key].Location, key)
+			}
+		}
+
+		fmt.Println("Configuration is valid")
 	},
 }
 
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade a configuration file to the current schema version",
+	Long: `Upgrade the user configuration file (or the project file with --project)
+to the current schema version. The original is kept with a .bak suffix.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		project, _ := cmd.Flags().GetBool("project")
+
+		path, err := configFilePath(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if project {
+			cwd, err := os.Getwd()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if path = config.FindProjectConfig(cwd); path == "" {
+				fmt.Fprintf(os.Stderr, "Error: no %s found in this directory or its parents\n", config.ProjectConfigPath)
+				os.Exit(1)
+			}
+		}
+
+		from, steps, err := config.MigrateFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error migrating configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(steps) == 0 {
+			fmt.Printf("%s is already at version %d\n", path, from)
+			return
+		}
+
+		for _, step := range steps {
+			fmt.Printf("  -> version %d: %s\n", step.To, step.Description)
+		}
+		fmt.Printf("Migrated %s from version %d to %d (backup: %s.bak)\n", path, from, steps[len(steps)-1].To, path)
+	},
+}
+
+// printRouting lists the AI profile and model each command uses
+func printRouting(cfg *config.Config, origins config.Origins, prefix string) {
+	commands := append([]string{}, aiCommands...)
+	for _, name := range cfg.RouteNames() {
+		if !contains(commands, name) {
+			commands = append(commands, name)
+		}
+	}
+
+	pinned := origins["profile"].Layer == config.LayerFlag
+	fmt.Printf("%sEffective AI routing:\n", prefix)
+	for _, command := range commands {
+		settings, selection, err := cfg.AIFor(command, pinned)
+		if err != nil {
+			fmt.Printf("%s  %-12s error: %v\n", prefix, command, err)
+			continue
+		}
+		fmt.Printf("%s  %-12s %s\n", prefix, command, describeSelection(settings, selection))
+	}
+}
+
+// contains reports whether list holds value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// secretProvider returns where 'config set' stores a secret, chosen by --store
+func secretProvider(cmd *cobra.Command, key string) (credentials.Provider, error) {
+	store, _ := cmd.Flags().GetString("store")
+
+	switch store {
+	case credentials.SchemeKeystore:
+		path, err := credentials.DefaultKeystorePath()
+		if err != nil {
+			return nil, err
+		}
+		return &credentials.KeystoreProvider{Path: path, Name: key, Passphrase: promptPassphrase}, nil
+	case credentials.SchemeFile:
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		return credentials.FileProvider{Path: filepath.Join(home, ".k3ss-ai", "credentials", key)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported store %q (use keystore or file, or pass env:NAME as the value)", store)
+	}
+}
+
+// promptPassphrase reads the keystore passphrase from K3SS_AI_KEYSTORE_PASSPHRASE
+// or, failing that, from the terminal without echoing it
+func promptPassphrase(confirm bool) (string, error) {
+	if passphrase := os.Getenv(credentials.PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	if !isTerminal(os.Stdin) {
+		return "", fmt.Errorf("the keystore is locked; set %s or run from a terminal", credentials.PassphraseEnv)
+	}
+
+	passphrase, err := readHidden("Keystore passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		again, err := readHidden("Confirm passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", fmt.Errorf("passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
+
+// readHidden prompts on stderr and reads one line from the terminal with echo
+// disabled. It reads byte by byte so no input meant for later prompts is consumed.
+func readHidden(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	defer fmt.Fprintln(os.Stderr)
+
+	stty := exec.Command("stty", "-echo")
+	stty.Stdin = os.Stdin
+	if stty.Run() == nil {
+		defer func() {
+			restore := exec.Command("stty", "echo")
+			restore.Stdin = os.Stdin
+			restore.Run()
+		}()
+	}
+
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if n == 1 {
+			if buf[0] == '\n' {
+				break
+			}
+			line = append(line, buf[0])
+		}
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return "", fmt.Errorf("failed to read passphrase: %w", err)
+		}
+	}
+	return strings.TrimRight(string(line), "\r"), nil
+}
+
+// configFilePath returns the file selected by the global --config flag
+func configFilePath(cmd *cobra.Command) (string, error) {
+	configPath, _ := cmd.Flags().GetString("config")
+	return config.ResolvePath(configPath)
+}
+
+// updateConfig applies update to the user configuration file selected by --config
+func updateConfig(cmd *cobra.Command, update func(path string) error) {
+	path, err := configFilePath(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := update(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
 func init() {
+	configShowCmd.Flags().StringP("format", "f", "yaml", "output format (yaml, json)")
+	configShowCmd.Flags().BoolP("origin", "", false, "show which layer each value comes from")
+	configInitCmd.Flags().BoolP("force", "", false, "overwrite an existing configuration file")
+	configSetCmd.Flags().StringP("store", "", credentials.SchemeKeystore, "where to store secrets such as ai.api_key (keystore, file)")
+	configMigrateCmd.Flags().BoolP("project", "", false, "migrate the project configuration instead of the user file")
+
 	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configGetCmd)
 	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
 	configCmd.AddCommand(configInitCmd)
-	
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configMigrateCmd)
+
 	rootCmd.AddCommand(configCmd)
 }
-
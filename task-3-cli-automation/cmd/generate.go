@@ -1,9 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
 
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/automation"
 	"github.com/spf13/cobra"
 )
 
@@ -56,9 +63,36 @@ var generateTestCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		file := args[0]
 		testType, _ := cmd.Flags().GetString("type")
+		framework, _ := cmd.Flags().GetString("framework")
+		
+		client, err := getAIClient(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating AI client: %v\n", err)
+			os.Exit(1)
+		}
 		
-		fmt.Printf("Generating %s tests for: %s\n", testType, file)
-		// TODO: Implement test generation
+		// Tests are written next to the file, wherever it is
+		dir := filepath.Dir(file)
+		processor := automation.NewBatchProcessor(dir)
+		processor.SetAIClient(client)
+		
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		
+		fmt.Printf("🧪 Generating %s tests for: %s\n", testType, file)
+		testFile, err := processor.GenerateTests(ctx, filepath.Base(file), automation.TestOptions{
+			Type:      testType,
+			Framework: framework,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating tests: %v\n", err)
+			var failed *automation.CommandError
+			if errors.As(err, &failed) {
+				fmt.Fprint(os.Stderr, failed.Output)
+			}
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Wrote %s\n", filepath.Join(dir, testFile))
 	},
 }
 
@@ -91,7 +125,7 @@ func init() {
 	
 	// Test generation flags
 	generateTestCmd.Flags().StringP("type", "t", "unit", "test type (unit, integration, e2e)")
-	generateTestCmd.Flags().StringP("framework", "f", "jest", "testing framework")
+	generateTestCmd.Flags().StringP("framework", "f", "", "testing framework (default: testing for Go, jest for JavaScript and TypeScript, pytest for Python)")
 	
 	// Scaffold generation flags
 	generateScaffoldCmd.Flags().StringP("name", "n", "", "project name")
@@ -31,6 +31,12 @@ Examples:
 		message, _ := cmd.Flags().GetString("message")
 		preview, _ := cmd.Flags().GetBool("preview")
 		
+		if !cmd.Flags().Changed("style") {
+			if cfg, err := loadConfig(cmd); err == nil && cfg.Git.CommitStyle != "" {
+				style = cfg.Git.CommitStyle
+			}
+		}
+		
 		gitService := git.NewGitService(".")
 		
 		// Check if we're in a git repository
@@ -57,6 +63,9 @@ Examples:
 			commitMessage = message
 		} else if analyze {
 			generator := git.NewCommitMessageGenerator(gitService)
+			if client, err := getAIClient(cmd); err == nil {
+				generator.SetAIClient(client)
+			}
 			commitMessage, err = generator.GenerateCommitMessage(style)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error generating commit message: %v\n", err)
@@ -137,11 +146,12 @@ var gitReviewCmd = &cobra.Command{
 		}
 		
 		fmt.Println("Reviewing changes...")
-		fmt.Println("Diff:")
-		fmt.Println(diff)
 		
-		// TODO: Implement AI-powered code review
-		fmt.Println("\nAI Review: Changes look good! Consider adding tests for new functionality.")
+		subject := "the staged changes"
+		if diffRange != "" {
+			subject = "the diff " + diffRange
+		}
+		runReview(cmd, reviewPrompt(subject, diff, "balanced", "markdown", nil))
 	},
 }
 
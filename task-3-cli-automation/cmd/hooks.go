@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/automation"
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/git"
+	"github.com/spf13/cobra"
+)
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Bind git_hook workflows to git hooks",
+	Long: `Install git hooks that run the workflows whose trigger is git_hook.
+
+Each hook is a small shim in .git/hooks (or core.hooksPath) that calls
+"k3ss-ai workflow trigger --event <hook>". A hook that already exists is
+kept and run first; if it fails, the workflows do not run.`,
+}
+
+var hooksInstallCmd = &cobra.Command{
+	Use:   "install [hook...]",
+	Short: "Install hooks for git_hook workflows",
+	Long: `Install a shim for every hook used by a git_hook workflow, or only the
+named hooks. Event names are accepted too: "push" installs pre-push and
+"commit:main" installs pre-commit.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		gitService, bindings := loadHookBindings()
+
+		hooks := resolveHookArgs(args)
+		if len(hooks) == 0 {
+			hooks = sortedKeys(bindings)
+		}
+		if len(hooks) == 0 {
+			fmt.Println("No git_hook workflows found; nothing to install")
+			return
+		}
+
+		executable, err := os.Executable()
+		if err == nil {
+			executable, err = filepath.EvalSymlinks(executable)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error locating k3ss-ai executable: %v\n", err)
+			os.Exit(1)
+		}
+
+		failed := false
+		for _, hook := range hooks {
+			if err := gitService.InstallHook(hook, executable); err != nil {
+				fmt.Fprintf(os.Stderr, "Error installing hook: %v\n", err)
+				failed = true
+				continue
+			}
+
+			fmt.Printf("✅ Installed %s hook", hook)
+			if len(bindings[hook]) > 0 {
+				fmt.Printf(" (runs %s)", strings.Join(bindings[hook], ", "))
+			}
+			fmt.Println()
+			if status, err := gitService.HookStatus(hook, ""); err == nil && status.Chained != "" {
+				fmt.Printf("   Existing hook kept as %s and run first\n", status.Chained)
+			}
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+var hooksUninstallCmd = &cobra.Command{
+	Use:   "uninstall [hook...]",
+	Short: "Remove installed hooks",
+	Long: `Remove every hook shim installed by k3ss-ai, or only the named hooks,
+restoring any hook they chained.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		gitService, _ := loadHookBindings()
+
+		hooks := resolveHookArgs(args)
+		if len(hooks) == 0 {
+			installed, err := gitService.InstalledHooks()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading hooks: %v\n", err)
+				os.Exit(1)
+			}
+			hooks = installed
+		}
+		if len(hooks) == 0 {
+			fmt.Println("No k3ss-ai hooks installed")
+			return
+		}
+
+		failed := false
+		for _, hook := range hooks {
+			if err := gitService.UninstallHook(hook); err != nil {
+				fmt.Fprintf(os.Stderr, "Error uninstalling hook: %v\n", err)
+				failed = true
+				continue
+			}
+			fmt.Printf("🗑️  Removed %s hook\n", hook)
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+var hooksStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which hooks are installed",
+	Run: func(cmd *cobra.Command, args []string) {
+		gitService, bindings := loadHookBindings()
+
+		installed, err := gitService.InstalledHooks()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading hooks: %v\n", err)
+			os.Exit(1)
+		}
+		hooks := sortedKeys(bindings)
+		for _, hook := range installed {
+			if _, bound := bindings[hook]; !bound {
+				hooks = append(hooks, hook)
+			}
+		}
+		sort.Strings(hooks)
+
+		if len(hooks) == 0 {
+			fmt.Println("No git_hook workflows and no k3ss-ai hooks installed")
+			return
+		}
+
+		executable, _ := os.Executable()
+		executable, _ = filepath.EvalSymlinks(executable)
+
+		fmt.Println("Git hooks:")
+		for _, hook := range hooks {
+			status, err := gitService.HookStatus(hook, executable)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading hook: %v\n", err)
+				os.Exit(1)
+			}
+
+			state := "❌ not installed"
+			switch {
+			case status.Installed && status.Current:
+				state = "✅ installed"
+			case status.Installed:
+				state = "⚠️  installed for a different k3ss-ai binary (run 'k3ss-ai hooks install')"
+			case status.Exists:
+				state = "⚠️  a hook not managed by k3ss-ai is present"
+			}
+			fmt.Printf("  %s: %s\n", hook, state)
+
+			if workflows := bindings[hook]; len(workflows) > 0 {
+				fmt.Printf("     Workflows: %s\n", strings.Join(workflows, ", "))
+			} else {
+				fmt.Println("     Workflows: none")
+			}
+			if status.Chained != "" {
+				fmt.Printf("     Chained: %s\n", status.Chained)
+			}
+		}
+	},
+}
+
+// loadHookBindings checks that we are in a git repository and returns the
+// hooks bound by the project's git_hook workflows
+func loadHookBindings() (*git.GitService, map[string][]string) {
+	gitService := git.NewGitService(".")
+	if !gitService.IsGitRepo() {
+		fmt.Fprintf(os.Stderr, "Error: Not in a git repository\n")
+		os.Exit(1)
+	}
+
+	automationService := automation.NewAutomationService(".")
+	if err := automationService.LoadWorkflows(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading workflows: %v\n", err)
+		os.Exit(1)
+	}
+	return gitService, automationService.HookBindings()
+}
+
+// resolveHookArgs maps hook and event names given on the command line, such
+// as "push" or "commit:main", to the git hooks they name, exiting on an
+// unknown one
+func resolveHookArgs(args []string) []string {
+	hooks, branched, err := automation.ResolveHooks(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	for _, event := range branched {
+		hook, branch, _ := automation.ParseEvent(event)
+		fmt.Printf("Note: %s means the %s hook, which runs on every branch; a workflow's events limit it to %s\n", event, hook, branch)
+	}
+	return hooks
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func init() {
+	hooksCmd.AddCommand(hooksInstallCmd)
+	hooksCmd.AddCommand(hooksUninstallCmd)
+	hooksCmd.AddCommand(hooksStatusCmd)
+
+	rootCmd.AddCommand(hooksCmd)
+}
@@ -3,10 +3,18 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/ai"
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/config"
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/credentials"
 	"github.com/spf13/cobra"
 )
 
+// sharedAIClient is created on first use so every command talks to the
+// orchestration service through the same client
+var sharedAIClient *ai.Client
+
 var rootCmd = &cobra.Command{
 	Use:   "k3ss-ai",
 	Short: "K3SS AI Coder - Ultimate AI Code Assistant CLI",
@@ -24,6 +32,93 @@ func init() {
 	rootCmd.PersistentFlags().StringP("config", "c", "", "config file (default is $HOME/.k3ss-ai.yaml)")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().BoolP("debug", "d", false, "debug mode")
+	rootCmd.PersistentFlags().StringP("profile", "", "", "AI profile to use, bypassing per-command routing")
+}
+
+// configFlags maps global flags to the configuration keys they override
+var configFlags = map[string]string{
+	"verbose": "settings.verbose",
+	"debug":   "settings.debug",
+	"profile": "profile",
+}
+
+// aiCommands lists the commands that talk to the AI service, for 'config show'
+var aiCommands = []string{"chat", "git commit", "git review", "review"}
+
+// loadConfig merges every configuration layer, using the file selected by the
+// global --config flag as the user layer
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	cfg, _, err := loadLayeredConfig(cmd)
+	return cfg, err
+}
+
+// loadLayeredConfig merges every configuration layer and reports where each value came from
+func loadLayeredConfig(cmd *cobra.Command) (*config.Config, config.Origins, error) {
+	configPath, _ := cmd.Flags().GetString("config")
+	
+	var flags []config.FlagOverride
+	for flagName, key := range configFlags {
+		if flag := cmd.Flags().Lookup(flagName); flag != nil && flag.Changed {
+			flags = append(flags, config.FlagOverride{Name: flagName, Key: key, Value: flag.Value.String()})
+		}
+	}
+	
+	return config.LoadLayered(config.LoadOptions{
+		UserPath: configPath,
+		Flags:    flags,
+	})
+}
+
+// getAIClient returns the shared AI client, creating it from configuration on first use
+func getAIClient(cmd *cobra.Command) (*ai.Client, error) {
+	if sharedAIClient != nil {
+		return sharedAIClient, nil
+	}
+	
+	cfg, origins, err := loadLayeredConfig(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	
+	settings, selection, err := cfg.AIFor(commandPath(cmd), origins["profile"].Layer == config.LayerFlag)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Settings.Debug {
+		fmt.Fprintf(os.Stderr, "Using %s\n", describeSelection(settings, selection))
+	}
+	
+	apiKey, err := credentials.Resolve(settings.APIKey, promptPassphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve api_key: %w", err)
+	}
+	settings.APIKey = apiKey
+
+	sharedAIClient = ai.NewClient(settings)
+	return sharedAIClient, nil
+}
+
+// commandPath returns the command's path without the program name, e.g. "git commit"
+func commandPath(cmd *cobra.Command) string {
+	return strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+}
+
+// describeSelection summarises which profile and model a command uses
+func describeSelection(settings config.AIConfig, selection config.Selection) string {
+	profile := selection.Profile
+	if profile == "" {
+		profile = "(ai)"
+	}
+
+	source := "default"
+	switch {
+	case selection.Pinned:
+		source = "--profile"
+	case selection.Route != "":
+		source = "routing." + selection.Route
+	}
+
+	return fmt.Sprintf("profile=%s model=%s endpoint=%s [%s]", profile, settings.Model, settings.Endpoint, source)
 }
 
 func main() {
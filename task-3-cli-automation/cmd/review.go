@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"strings"
 
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/ai"
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/git"
 	"github.com/spf13/cobra"
 )
 
@@ -31,7 +36,19 @@ var reviewDiffCmd = &cobra.Command{
 		fmt.Printf("Review style: %s\n", style)
 		fmt.Printf("Checklist: %v\n", checklist)
 		fmt.Printf("Output format: %s\n", format)
-		// TODO: Implement diff review
+		
+		diff, err := git.NewGitService(".").GetDiff(diffRange)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting diff: %v\n", err)
+			os.Exit(1)
+		}
+		
+		if diff == "" {
+			fmt.Println("No changes to review")
+			return
+		}
+		
+		runReview(cmd, reviewPrompt("the diff "+diffRange, diff, style, format, checklist))
 	},
 }
 
@@ -47,7 +64,19 @@ var reviewBranchCmd = &cobra.Command{
 		fmt.Printf("Reviewing branch: %s\n", branch)
 		fmt.Printf("Base branch: %s\n", base)
 		fmt.Printf("Checklist: %v\n", checklist)
-		// TODO: Implement branch review
+		
+		diff, err := git.NewGitService(".").GetDiff(base + "..." + branch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting diff: %v\n", err)
+			os.Exit(1)
+		}
+		
+		if diff == "" {
+			fmt.Println("No changes to review")
+			return
+		}
+		
+		runReview(cmd, reviewPrompt("branch "+branch+" against "+base, diff, "balanced", "markdown", checklist))
 	},
 }
 
@@ -63,7 +92,15 @@ var reviewFileCmd = &cobra.Command{
 		fmt.Printf("Reviewing file: %s\n", file)
 		fmt.Printf("Review style: %s\n", style)
 		fmt.Printf("Focus areas: %v\n", focus)
-		// TODO: Implement file review
+		
+		fileInfo, err := ai.FileFromPath(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		
+		// The file goes as an attachment only, so its content is not sent twice
+		runReview(cmd, reviewPrompt("the attached file "+fileInfo.Path, "", style, "markdown", focus), fileInfo)
 	},
 }
 
@@ -85,22 +122,62 @@ var reviewPRCmd = &cobra.Command{
 	},
 }
 
+// reviewPrompt builds the review instructions sent to the AI service,
+// followed by content unless it is attached instead
+func reviewPrompt(subject, content, style, format string, checklist []string) string {
+	var prompt strings.Builder
+	
+	prompt.WriteString(fmt.Sprintf("Review %s.\n", subject))
+	prompt.WriteString(fmt.Sprintf("Review style: %s.\n", style))
+	if len(checklist) > 0 {
+		prompt.WriteString(fmt.Sprintf("Focus on: %s.\n", strings.Join(checklist, ", ")))
+	}
+	prompt.WriteString(fmt.Sprintf("Respond in %s format.\n", format))
+	if content != "" {
+		prompt.WriteString("\n")
+		prompt.WriteString(content)
+	}
+	
+	return prompt.String()
+}
+
+// runReview sends a review prompt to the AI service and prints the result
+func runReview(cmd *cobra.Command, prompt string, files ...ai.FileInfo) {
+	client, err := getAIClient(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	
+	response, err := client.Ask(context.Background(), ai.RequestAnalyze, prompt, files...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error requesting review: %v\n", err)
+		os.Exit(1)
+	}
+	
+	fmt.Println("\nAI Review:")
+	fmt.Println(response.Content)
+	for _, suggestion := range response.Suggestions {
+		fmt.Printf("  💡 %s\n", suggestion)
+	}
+}
+
 func init() {
 	// Diff review flags
-	reviewDiffCmd.Flags().StringSliceP("checklist", "c", []string{"security", "performance", "style"}, "review checklist items")
+	reviewDiffCmd.Flags().StringSliceP("checklist", "", []string{"security", "performance", "style"}, "review checklist items")
 	reviewDiffCmd.Flags().StringP("style", "s", "balanced", "review style (strict, balanced, lenient)")
 	reviewDiffCmd.Flags().StringP("format", "f", "markdown", "output format (markdown, text, json)")
 	
 	// Branch review flags
 	reviewBranchCmd.Flags().StringP("base", "b", "main", "base branch for comparison")
-	reviewBranchCmd.Flags().StringSliceP("checklist", "c", []string{"security", "performance", "style"}, "review checklist items")
+	reviewBranchCmd.Flags().StringSliceP("checklist", "", []string{"security", "performance", "style"}, "review checklist items")
 	
 	// File review flags
 	reviewFileCmd.Flags().StringP("style", "s", "balanced", "review style (strict, balanced, lenient)")
 	reviewFileCmd.Flags().StringSliceP("focus", "f", []string{}, "focus areas (security, performance, style, logic)")
 	
 	// PR review flags
-	reviewPRCmd.Flags().StringSliceP("checklist", "c", []string{"security", "performance", "style"}, "review checklist items")
+	reviewPRCmd.Flags().StringSliceP("checklist", "", []string{"security", "performance", "style"}, "review checklist items")
 	reviewPRCmd.Flags().BoolP("auto-comment", "a", false, "automatically post review comments")
 	
 	// Add subcommands
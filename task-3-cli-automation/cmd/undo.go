@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/automation"
+	"github.com/spf13/cobra"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo [run-id]",
+	Short: "Restore the files changed by a batch operation",
+	Long: `Restore the files a batch operation changed from the journal it recorded
+in .k3ss-ai/journal, or those of the latest run that has not been undone.
+
+Files changed again since the run are not overwritten unless --force is
+given; files the run created are removed.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		list, _ := cmd.Flags().GetBool("list")
+		force, _ := cmd.Flags().GetBool("force")
+
+		journals, err := automation.LoadJournals(".")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading journals: %v\n", err)
+			os.Exit(1)
+		}
+
+		if list {
+			if len(journals) == 0 {
+				fmt.Println("No journaled runs found")
+				return
+			}
+			fmt.Println("📒 Journaled runs:")
+			for _, journal := range journals {
+				fmt.Printf("  %s  %-14s %-11s %d file(s)  %s\n", journal.ID, journal.Operation,
+					journal.Status, len(journal.Files), journal.StartTime.Local().Format("2006-01-02 15:04:05"))
+			}
+			return
+		}
+
+		var journal *automation.Journal
+		if len(args) == 1 {
+			journal, err = automation.LoadJournal(".", args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading journal: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			for _, candidate := range journals {
+				if candidate.Status != automation.JournalUndone && candidate.Status != automation.JournalRolledBack {
+					journal = candidate
+					break
+				}
+			}
+			if journal == nil {
+				fmt.Println("Nothing to undo")
+				return
+			}
+		}
+
+		restored, err := journal.Undo(force)
+		if err != nil {
+			var conflict *automation.ConflictError
+			if errors.As(err, &conflict) {
+				fmt.Fprintf(os.Stderr, "Error undoing run %s: these files changed since: %s\n", journal.ID, strings.Join(conflict.Files, ", "))
+				fmt.Fprintln(os.Stderr, "Use --force to overwrite them")
+			} else {
+				fmt.Fprintf(os.Stderr, "Error undoing run %s: %v\n", journal.ID, err)
+			}
+			os.Exit(1)
+		}
+
+		fmt.Printf("↩️  Undid %s run %s\n", journal.Operation, journal.ID)
+		for _, file := range restored {
+			fmt.Printf("  - %s\n", file)
+		}
+	},
+}
+
+func init() {
+	undoCmd.Flags().Bool("list", false, "list the journaled runs instead of undoing one")
+	undoCmd.Flags().Bool("force", false, "restore files even if they changed since the run")
+
+	rootCmd.AddCommand(undoCmd)
+}
@@ -2,9 +2,14 @@ module github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation
 
 go 1.21.5
 
+require (
+	github.com/gorilla/mux v1.8.1
+	github.com/rs/cors v1.11.1
+	github.com/spf13/cobra v1.9.1
+	gopkg.in/yaml.v3 v3.0.1
+)
+
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/cobra v1.9.1 // indirect
 	github.com/spf13/pflag v1.0.6 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,301 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/config"
+)
+
+const (
+	defaultTimeout    = 30 * time.Second
+	defaultMaxRetries = 3
+	defaultBackoff    = 500 * time.Millisecond
+	maxBackoff        = 10 * time.Second
+)
+
+// Client talks to the AI orchestration service over HTTP
+type Client struct {
+	endpoint   string
+	apiKey     string
+	model      string
+	timeout    time.Duration
+	maxRetries int
+	backoff    time.Duration
+	httpClient *http.Client
+}
+
+// NewClient creates a new AI client from the AI configuration block
+func NewClient(cfg config.AIConfig) *Client {
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Client{
+		endpoint:   strings.TrimRight(cfg.Endpoint, "/"),
+		apiKey:     cfg.APIKey,
+		model:      cfg.Model,
+		timeout:    timeout,
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultBackoff,
+		httpClient: &http.Client{},
+	}
+}
+
+// SetRetryPolicy overrides the number of retries and the initial backoff delay
+func (c *Client) SetRetryPolicy(maxRetries int, backoff time.Duration) {
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	c.maxRetries = maxRetries
+	c.backoff = backoff
+}
+
+// Model returns the default model used for requests
+func (c *Client) Model() string {
+	return c.model
+}
+
+// envelope is the standard response wrapper used by the orchestration service
+type envelope struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Error   *struct {
+		Code    string      `json:"code"`
+		Message string      `json:"message"`
+		Details interface{} `json:"details"`
+	} `json:"error"`
+}
+
+// Send sends a request to the orchestration service and waits for the full response
+func (c *Client) Send(ctx context.Context, req *Request) (*Response, error) {
+	if req.Model == "" {
+		req.Model = c.model
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal AI request: %w", err)
+	}
+
+	var response Response
+	if err := c.do(ctx, http.MethodPost, "/ai/request", body, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Ask is a convenience wrapper that sends content with optional attached files
+func (c *Client) Ask(ctx context.Context, requestType RequestType, content string, files ...FileInfo) (*Response, error) {
+	req := NewRequest(requestType, content)
+	req.AttachFiles(files...)
+	return c.Send(ctx, req)
+}
+
+// Health checks that the orchestration service is reachable
+func (c *Client) Health(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/health", nil, nil)
+}
+
+// do performs a request with retries and decodes the envelope data into out
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	if c.endpoint == "" {
+		return ErrNoEndpoint
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var lastErr error
+	attempts := 0
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, c.retryDelay(attempt, lastErr)); err != nil {
+				break
+			}
+		}
+
+		attempts++
+		lastErr = c.attempt(ctx, method, path, body, out)
+		if lastErr == nil {
+			return nil
+		}
+		if ctx.Err() != nil || !IsRetryable(lastErr) {
+			break
+		}
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &TimeoutError{Timeout: c.timeout, Attempts: attempts}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return lastErr
+}
+
+// attempt performs a single HTTP round trip
+func (c *Client) attempt(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	resp, err := c.open(ctx, method, path, body, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &ConnectionError{Endpoint: c.endpoint, Err: err}
+	}
+
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		if resp.StatusCode >= 400 {
+			return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data)), RetryAfter: retryAfter(resp)}
+		}
+		return fmt.Errorf("failed to decode AI response: %w", err)
+	}
+
+	if resp.StatusCode >= 400 || !env.Success {
+		apiErr := &APIError{
+			StatusCode: resp.StatusCode,
+			Message:    http.StatusText(resp.StatusCode),
+			RetryAfter: retryAfter(resp),
+		}
+		if env.Error != nil {
+			apiErr.Code = env.Error.Code
+			apiErr.Message = env.Error.Message
+			apiErr.Details = env.Error.Details
+		}
+		return apiErr
+	}
+
+	if out != nil && len(env.Data) > 0 {
+		if err := json.Unmarshal(env.Data, out); err != nil {
+			return fmt.Errorf("failed to decode AI response data: %w", err)
+		}
+	}
+	return nil
+}
+
+// open sends an HTTP request and returns the raw response
+func (c *Client) open(ctx context.Context, method, path string, body []byte, accept string) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AI request: %w", err)
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("Accept", accept)
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, &ConnectionError{Endpoint: c.endpoint, Err: err}
+	}
+	return resp, nil
+}
+
+// retryDelay computes the exponential backoff delay for the given attempt
+func (c *Client) retryDelay(attempt int, lastErr error) time.Duration {
+	var apiErr *APIError
+	if errors.As(lastErr, &apiErr) {
+		if apiErr.RetryAfter > 0 {
+			return apiErr.RetryAfter
+		}
+	}
+
+	delay := c.backoff << uint(attempt-1)
+	if delay > maxBackoff || delay <= 0 {
+		delay = maxBackoff
+	}
+	return delay
+}
+
+// retryAfter parses the Retry-After header as a delay, if present
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// sleepContext waits for the given duration or until the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// newRequestID generates a request identifier
+func newRequestID(now time.Time) string {
+	return fmt.Sprintf("cli-%d", now.UnixNano())
+}
+
+// FileFromPath reads a file from disk and wraps it for attachment to a request
+func FileFromPath(path string) (FileInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return FileInfo{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	info := FileInfo{
+		Path:     path,
+		Content:  string(data),
+		Language: LanguageForPath(path),
+		Size:     len(data),
+	}
+	if stat, err := os.Stat(path); err == nil {
+		info.LastModified = stat.ModTime().UTC().Format(time.RFC3339)
+	}
+	return info, nil
+}
+
+// LanguageForPath guesses the language of a file from its extension
+func LanguageForPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		return "go"
+	case ".js", ".jsx", ".mjs":
+		return "javascript"
+	case ".ts", ".tsx":
+		return "typescript"
+	case ".py":
+		return "python"
+	case ".rs":
+		return "rust"
+	case ".java":
+		return "java"
+	case ".md":
+		return "markdown"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".json":
+		return "json"
+	default:
+		return "text"
+	}
+}
@@ -0,0 +1,67 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrNoEndpoint is returned when no orchestration endpoint is configured
+var ErrNoEndpoint = errors.New("no AI endpoint configured (set ai.endpoint)")
+
+// APIError represents an error reported by the orchestration service
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Details    interface{}
+	// RetryAfter is the delay requested by the service via Retry-After, if any
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("AI service error %d (%s): %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("AI service error %d: %s", e.StatusCode, e.Message)
+}
+
+// Retryable reports whether the request may succeed if sent again
+func (e *APIError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// ConnectionError represents a failure to reach the orchestration service
+type ConnectionError struct {
+	Endpoint string
+	Err      error
+}
+
+func (e *ConnectionError) Error() string {
+	return fmt.Sprintf("failed to reach AI service at %s: %v", e.Endpoint, e.Err)
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// TimeoutError is returned when a request exceeds the configured timeout
+type TimeoutError struct {
+	Timeout  time.Duration
+	Attempts int
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("AI request timed out after %v (%d attempts)", e.Timeout, e.Attempts)
+}
+
+// IsRetryable reports whether err is a transient failure worth retrying
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+	var connErr *ConnectionError
+	return errors.As(err, &connErr)
+}
@@ -0,0 +1,269 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StreamChunk is a single piece of a streamed response
+type StreamChunk struct {
+	Content string `json:"content"`
+	Done    bool   `json:"done"`
+	Model   string `json:"model,omitempty"`
+	Error   *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// StreamHandler receives streamed tokens as they arrive
+type StreamHandler func(token string) error
+
+// Stream sends a request and delivers the response tokens to handler as they arrive.
+// The service may answer with server-sent events, newline-delimited JSON chunks, or
+// a regular JSON envelope, in which case the full content is delivered at once.
+// The configured timeout applies to the wait for each chunk rather than the whole stream.
+func (c *Client) Stream(ctx context.Context, req *Request, handler StreamHandler) (*Response, error) {
+	if c.endpoint == "" {
+		return nil, ErrNoEndpoint
+	}
+	if req.Model == "" {
+		req.Model = c.model
+	}
+	if req.Options == nil {
+		req.Options = &Options{}
+	}
+	req.Options.Stream = true
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal AI request: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	idle := newIdleTimer(c.timeout, cancel)
+	defer idle.stop()
+
+	resp, attempts, err := c.openStream(ctx, body)
+	if err != nil {
+		if idle.expired() {
+			return nil, &TimeoutError{Timeout: c.timeout, Attempts: attempts}
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	response := &Response{ID: req.ID, Model: req.Model}
+	var content strings.Builder
+	deliver := func(token string) error {
+		idle.reset()
+		if token == "" {
+			return nil
+		}
+		content.WriteString(token)
+		return handler(token)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	switch {
+	case strings.HasPrefix(contentType, "text/event-stream"):
+		err = readEventStream(resp.Body, response, deliver)
+	case strings.HasPrefix(contentType, "application/x-ndjson"):
+		err = readChunkedJSON(resp.Body, response, deliver)
+	default:
+		err = readEnvelope(resp.Body, response, deliver)
+	}
+
+	if err != nil {
+		if idle.expired() {
+			return nil, &TimeoutError{Timeout: c.timeout, Attempts: attempts}
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	response.Content = content.String()
+	return response, nil
+}
+
+// openStream opens the streaming connection, retrying transient failures
+// that happen before any data has been received
+func (c *Client) openStream(ctx context.Context, body []byte) (*http.Response, int, error) {
+	var lastErr error
+	attempts := 0
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, c.retryDelay(attempt, lastErr)); err != nil {
+				return nil, attempts, err
+			}
+		}
+
+		attempts++
+		resp, err := c.open(ctx, http.MethodPost, "/ai/request", body, "text/event-stream, application/x-ndjson, application/json")
+		if err == nil && resp.StatusCode >= 400 {
+			err = decodeErrorResponse(resp)
+			resp.Body.Close()
+		}
+		if err == nil {
+			return resp, attempts, nil
+		}
+
+		lastErr = err
+		if ctx.Err() != nil {
+			return nil, attempts, ctx.Err()
+		}
+		if !IsRetryable(err) {
+			break
+		}
+	}
+	return nil, attempts, lastErr
+}
+
+// decodeErrorResponse converts an HTTP error response into an APIError
+func decodeErrorResponse(resp *http.Response) error {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    http.StatusText(resp.StatusCode),
+		RetryAfter: retryAfter(resp),
+	}
+
+	var env envelope
+	if data, err := io.ReadAll(resp.Body); err == nil && json.Unmarshal(data, &env) == nil && env.Error != nil {
+		apiErr.Code = env.Error.Code
+		apiErr.Message = env.Error.Message
+		apiErr.Details = env.Error.Details
+	}
+	return apiErr
+}
+
+// readEventStream reads server-sent events where each data line holds a StreamChunk
+func readEventStream(body io.Reader, response *Response, deliver StreamHandler) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return nil
+		}
+
+		done, err := handleChunk([]byte(data), response, deliver)
+		if err != nil || done {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// readChunkedJSON reads newline-delimited StreamChunk objects
+func readChunkedJSON(body io.Reader, response *Response, deliver StreamHandler) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		done, err := handleChunk([]byte(line), response, deliver)
+		if err != nil || done {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// readEnvelope handles services that answer a stream request with a single JSON envelope
+func readEnvelope(body io.Reader, response *Response, deliver StreamHandler) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return fmt.Errorf("failed to decode AI response: %w", err)
+	}
+	if !env.Success {
+		apiErr := &APIError{StatusCode: http.StatusOK, Message: "request failed"}
+		if env.Error != nil {
+			apiErr.Code = env.Error.Code
+			apiErr.Message = env.Error.Message
+		}
+		return apiErr
+	}
+
+	var full Response
+	if err := json.Unmarshal(env.Data, &full); err != nil {
+		return fmt.Errorf("failed to decode AI response data: %w", err)
+	}
+	*response = full
+	return deliver(full.Content)
+}
+
+// handleChunk decodes one chunk and reports whether the stream is complete
+func handleChunk(data []byte, response *Response, deliver StreamHandler) (bool, error) {
+	var chunk StreamChunk
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return false, fmt.Errorf("failed to decode stream chunk: %w", err)
+	}
+	if chunk.Error != nil {
+		return false, &APIError{StatusCode: http.StatusOK, Code: chunk.Error.Code, Message: chunk.Error.Message}
+	}
+	if chunk.Model != "" {
+		response.Model = chunk.Model
+	}
+	if err := deliver(chunk.Content); err != nil {
+		return false, err
+	}
+	return chunk.Done, nil
+}
+
+// idleTimer cancels a stream when no data arrives within the timeout
+type idleTimer struct {
+	mu      sync.Mutex
+	timer   *time.Timer
+	timeout time.Duration
+	fired   bool
+}
+
+func newIdleTimer(timeout time.Duration, cancel context.CancelFunc) *idleTimer {
+	t := &idleTimer{timeout: timeout}
+	t.timer = time.AfterFunc(timeout, func() {
+		t.mu.Lock()
+		t.fired = true
+		t.mu.Unlock()
+		cancel()
+	})
+	return t
+}
+
+func (t *idleTimer) reset() {
+	t.timer.Reset(t.timeout)
+}
+
+func (t *idleTimer) stop() {
+	t.timer.Stop()
+}
+
+func (t *idleTimer) expired() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.fired
+}
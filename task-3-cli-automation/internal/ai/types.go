@@ -0,0 +1,104 @@
+package ai
+
+import "time"
+
+// RequestType identifies the kind of work the orchestration service should perform
+type RequestType string
+
+const (
+	RequestChat     RequestType = "chat"
+	RequestGenerate RequestType = "generate"
+	RequestAnalyze  RequestType = "analyze"
+	RequestRefactor RequestType = "refactor"
+	RequestExplain  RequestType = "explain"
+	RequestDebug    RequestType = "debug"
+)
+
+// Request represents a request to the AI orchestration service
+type Request struct {
+	ID        string         `json:"id"`
+	Type      RequestType    `json:"type"`
+	Content   string         `json:"content"`
+	Context   ProjectContext `json:"context"`
+	Model     string         `json:"model,omitempty"`
+	Options   *Options       `json:"options,omitempty"`
+	Timestamp string         `json:"timestamp"`
+}
+
+// ProjectContext describes the project the request refers to
+type ProjectContext struct {
+	Files       []FileInfo  `json:"files"`
+	CurrentFile string      `json:"currentFile,omitempty"`
+	GitInfo     *GitContext `json:"gitInfo,omitempty"`
+	ProjectRoot string      `json:"projectRoot"`
+	Language    string      `json:"language,omitempty"`
+}
+
+// FileInfo describes a file attached to a request
+type FileInfo struct {
+	Path         string `json:"path"`
+	Content      string `json:"content,omitempty"`
+	Language     string `json:"language"`
+	Size         int    `json:"size"`
+	LastModified string `json:"lastModified"`
+}
+
+// GitContext describes the git state of the project
+type GitContext struct {
+	Branch string   `json:"branch"`
+	Commit string   `json:"commit"`
+	Status []string `json:"status"`
+}
+
+// Options tunes model behaviour for a single request
+type Options struct {
+	Temperature float64 `json:"temperature,omitempty"`
+	MaxTokens   int     `json:"maxTokens,omitempty"`
+	Stream      bool    `json:"stream,omitempty"`
+	Timeout     int     `json:"timeout,omitempty"`
+}
+
+// Response represents the orchestration service's answer to a request
+type Response struct {
+	ID          string           `json:"id"`
+	Content     string           `json:"content"`
+	Metadata    ResponseMetadata `json:"metadata"`
+	Confidence  float64          `json:"confidence"`
+	Suggestions []string         `json:"suggestions,omitempty"`
+	Model       string           `json:"model"`
+	Provider    string           `json:"provider"`
+	Timestamp   string           `json:"timestamp"`
+}
+
+// ResponseMetadata carries usage information about a response
+type ResponseMetadata struct {
+	TokensUsed   int    `json:"tokensUsed"`
+	ResponseTime int64  `json:"responseTime"`
+	Model        string `json:"model"`
+	Provider     string `json:"provider"`
+	Cached       bool   `json:"cached"`
+}
+
+// NewRequest creates a request with a generated ID and timestamp
+func NewRequest(requestType RequestType, content string) *Request {
+	now := time.Now()
+	return &Request{
+		ID:        newRequestID(now),
+		Type:      requestType,
+		Content:   content,
+		Context:   ProjectContext{Files: []FileInfo{}, ProjectRoot: "."},
+		Timestamp: now.UTC().Format(time.RFC3339),
+	}
+}
+
+// AttachFiles adds files to the request context, making the first one the current file
+func (r *Request) AttachFiles(files ...FileInfo) {
+	if len(files) == 0 {
+		return
+	}
+	r.Context.Files = append(r.Context.Files, files...)
+	if r.Context.CurrentFile == "" {
+		r.Context.CurrentFile = files[0].Path
+		r.Context.Language = files[0].Language
+	}
+}
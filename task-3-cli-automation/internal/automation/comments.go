@@ -0,0 +1,271 @@
+package automation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/ai"
+)
+
+// addComments asks the AI service for documentation comments on the
+// file's undocumented public symbols and writes them as a patch to
+// PatchDir for review. With apply, a patch already waiting for the file is
+// applied as it is, and otherwise the new one is applied straight away.
+func (b *BatchProcessor) addComments(ctx context.Context, file string, apply bool) error {
+	pending := patchPath(b.projectPath, file)
+	if apply {
+		if patch, err := os.ReadFile(pending); err == nil {
+			b.logf("Applying %s\n", filepath.Join(PatchDir, file+".patch"))
+			if err := b.applyFilePatch(file, string(patch)); err != nil {
+				return err
+			}
+			return os.Remove(pending)
+		}
+	}
+
+	b.logf("Adding comments to %s\n", file)
+	if b.aiClient == nil {
+		return fmt.Errorf("comment generation needs the AI service")
+	}
+
+	path := filepath.Join(b.projectPath, file)
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	symbols, err := findSymbols(file, src)
+	if err != nil {
+		return err
+	}
+	var undocumented []codeSymbol
+	for _, symbol := range symbols {
+		if !symbol.Documented {
+			undocumented = append(undocumented, symbol)
+		}
+	}
+	if len(undocumented) == 0 {
+		b.logf("%s: every public symbol is documented\n", file)
+		return nil
+	}
+
+	comments, err := b.requestComments(ctx, file, src, undocumented)
+	if err != nil {
+		return err
+	}
+
+	inserts := make(map[int][]string)
+	count := 0
+	for _, symbol := range undocumented {
+		text := cleanComment(comments[symbol.Name])
+		if text == "" {
+			continue
+		}
+		line, lines := commentLines(src, sourceLanguage(file), symbol, text)
+		inserts[line] = append(inserts[line], lines...)
+		count++
+	}
+	if count == 0 {
+		return fmt.Errorf("AI service returned no comments for %s", file)
+	}
+
+	content := insertLines(string(src), inserts)
+	if sourceLanguage(file) == "go" {
+		if err := sameGoCode(file, src, []byte(content)); err != nil {
+			return fmt.Errorf("refusing generated comments: %w", err)
+		}
+	}
+
+	patch := insertionPatch(file, string(src), inserts)
+	if apply {
+		return b.applyFilePatch(file, patch)
+	}
+	if err := os.MkdirAll(filepath.Dir(pending), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(pending, []byte(patch), 0644); err != nil {
+		return err
+	}
+	b.logf("Wrote %s (%d comment(s))\n", filepath.Join(PatchDir, file+".patch"), count)
+	return nil
+}
+
+// requestComments asks the AI service for a comment per symbol, returned
+// as a JSON object keyed by symbol name
+func (b *BatchProcessor) requestComments(ctx context.Context, file string, src []byte, symbols []codeSymbol) (map[string]string, error) {
+	language := sourceLanguage(file)
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Write documentation comments for these public symbols of %s, following %s conventions", file, language)
+	if language == "go" {
+		prompt.WriteString(" (each comment is a sentence starting with the symbol's name, without its receiver)")
+	}
+	prompt.WriteString(":\n\n")
+	for _, symbol := range symbols {
+		fmt.Fprintf(&prompt, "- %s (%s): %s\n", symbol.Name, symbol.Kind, symbol.Signature)
+	}
+	prompt.WriteString("\nReply with only a JSON object mapping each symbol name above to its comment text, without comment markers.")
+
+	response, err := b.aiClient.Ask(ctx, ai.RequestGenerate, prompt.String(), ai.FileInfo{
+		Path:     file,
+		Content:  string(src),
+		Language: language,
+		Size:     len(src),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	content := response.Content
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("AI service did not reply with a JSON object")
+	}
+	var comments map[string]string
+	if err := json.Unmarshal([]byte(content[start:end+1]), &comments); err != nil {
+		return nil, fmt.Errorf("could not parse the AI service's reply: %w", err)
+	}
+	return comments, nil
+}
+
+// cleanComment trims a comment and drops any comment markers around it
+func cleanComment(text string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		line = strings.TrimSpace(line)
+		for _, marker := range []string{"/**", "/*", "//", "*/", "*", "#", `"""`} {
+			line = strings.TrimSpace(strings.TrimPrefix(line, marker))
+		}
+		line = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(line, `"""`), "*/"))
+		lines = append(lines, line)
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// commentLines returns the lines documenting symbol with text in the
+// file's language and the 0-based line they go before
+func commentLines(src []byte, language string, symbol codeSymbol, text string) (int, []string) {
+	textLines := strings.Split(text, "\n")
+	var lines []string
+	switch language {
+	case "python":
+		// A body sharing the header's line has no room for a docstring, so
+		// comments go above the declaration
+		if symbol.BodyLine == 0 {
+			for _, line := range textLines {
+				lines = append(lines, strings.TrimRight(symbol.Indent+"# "+line, " "))
+			}
+			return symbol.Line - 1, lines
+		}
+
+		// Docstrings open the body, at its indentation
+		source := strings.Split(string(src), "\n")
+		body := symbol.Indent + "    "
+		for _, next := range source[symbol.BodyLine:] {
+			if trimmed := strings.TrimLeft(next, " \t"); trimmed != "" {
+				if len(next)-len(trimmed) > len(symbol.Indent) {
+					body = next[:len(next)-len(trimmed)]
+				}
+				break
+			}
+		}
+		for i := range textLines {
+			textLines[i] = strings.ReplaceAll(textLines[i], `"""`, `\"\"\"`)
+		}
+		if len(textLines) == 1 {
+			return symbol.BodyLine, []string{body + `"""` + textLines[0] + `"""`}
+		}
+		for i, line := range textLines {
+			switch {
+			case i == 0:
+				lines = append(lines, body+`"""`+line)
+			case line == "":
+				lines = append(lines, "")
+			default:
+				lines = append(lines, body+line)
+			}
+		}
+		return symbol.BodyLine, append(lines, body+`"""`)
+
+	case "go":
+		for _, line := range textLines {
+			lines = append(lines, strings.TrimRight(symbol.Indent+"// "+line, " "))
+		}
+
+	default:
+		// JSDoc
+		lines = append(lines, symbol.Indent+"/**")
+		for _, line := range textLines {
+			lines = append(lines, strings.TrimRight(symbol.Indent+" * "+strings.ReplaceAll(line, "*/", "*\\/"), " "))
+		}
+		lines = append(lines, symbol.Indent+" */")
+	}
+	return symbol.Line - 1, lines
+}
+
+// sameGoCode checks that updated is valid Go with the same tokens as
+// original once comments are ignored, so only comments were added
+func sameGoCode(file string, original, updated []byte) error {
+	if _, err := parser.ParseFile(token.NewFileSet(), file, updated, parser.ParseComments); err != nil {
+		return err
+	}
+	tokens := func(src []byte) []string {
+		fset := token.NewFileSet()
+		var s scanner.Scanner
+		s.Init(fset.AddFile(file, -1, len(src)), src, nil, 0)
+		var tokens []string
+		for {
+			_, tok, lit := s.Scan()
+			if tok == token.EOF {
+				return tokens
+			}
+			if tok == token.SEMICOLON && lit == "\n" {
+				continue // automatic semicolons move with the comments
+			}
+			tokens = append(tokens, tok.String()+lit)
+		}
+	}
+	before, after := tokens(original), tokens(updated)
+	if len(before) != len(after) {
+		return fmt.Errorf("%s: code changed", file)
+	}
+	for i := range before {
+		if before[i] != after[i] {
+			return fmt.Errorf("%s: code changed", file)
+		}
+	}
+	return nil
+}
+
+// applyFilePatch applies a patch to a project-relative file, refusing one
+// that would leave a Go file unparseable
+func (b *BatchProcessor) applyFilePatch(file, patch string) error {
+	path := filepath.Join(b.projectPath, file)
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	content, err := applyPatch(string(src), patch)
+	if err != nil {
+		return fmt.Errorf("applying patch to %s: %w", file, err)
+	}
+	if sourceLanguage(file) == "go" {
+		if _, err := parser.ParseFile(token.NewFileSet(), file, content, parser.ParseComments); err != nil {
+			return fmt.Errorf("patched file would not parse: %w", err)
+		}
+	}
+	if bytes.Equal(src, []byte(content)) {
+		return nil
+	}
+	return os.WriteFile(path, []byte(content), info.Mode().Perm())
+}
@@ -0,0 +1,155 @@
+package automation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// stepGraph is the dependency graph of a workflow's steps. Steps are
+// identified by their index in the workflow.
+type stepGraph struct {
+	ids   []string
+	needs [][]int
+}
+
+// graphError describes a dependency problem at a specific step
+type graphError struct {
+	step    int
+	message string
+}
+
+func (e *graphError) Error() string {
+	return e.message
+}
+
+// StepID returns the identifier other steps use in needs: the step's id,
+// or "step-N" for the Nth step when it has none
+func StepID(index int, step WorkflowStep) string {
+	if step.ID != "" {
+		return step.ID
+	}
+	return fmt.Sprintf("step-%d", index+1)
+}
+
+// HandlerStepID returns the identifier of an on_failure or always step: the
+// step's id, or "<phase>-N" for the Nth handler when it has none
+func HandlerStepID(phase string, index int, step WorkflowStep) string {
+	if step.ID != "" {
+		return step.ID
+	}
+	return fmt.Sprintf("%s-%d", phase, index+1)
+}
+
+// buildStepGraph resolves needs into step indices and rejects duplicate ids,
+// unknown dependencies and cycles. When no step declares needs, every step
+// waits for the one before it so older workflows keep running in sequence.
+func buildStepGraph(steps []WorkflowStep) (*stepGraph, error) {
+	graph := &stepGraph{
+		ids:   make([]string, len(steps)),
+		needs: make([][]int, len(steps)),
+	}
+
+	index := make(map[string]int, len(steps))
+	explicit := false
+	for i, step := range steps {
+		id := StepID(i, step)
+		if previous, exists := index[id]; exists {
+			return nil, &graphError{step: i, message: fmt.Sprintf("step id %q is already used by step %d", id, previous+1)}
+		}
+		index[id] = i
+		graph.ids[i] = id
+		explicit = explicit || len(step.Needs) > 0
+	}
+
+	for i, step := range steps {
+		if !explicit {
+			if i > 0 {
+				graph.needs[i] = []int{i - 1}
+			}
+			continue
+		}
+
+		for _, need := range step.Needs {
+			dependency, ok := index[need]
+			if !ok {
+				return nil, &graphError{step: i, message: fmt.Sprintf("step %q needs unknown step %q", graph.ids[i], need)}
+			}
+			if dependency == i {
+				return nil, &graphError{step: i, message: fmt.Sprintf("step %q needs itself", graph.ids[i])}
+			}
+			graph.needs[i] = append(graph.needs[i], dependency)
+		}
+	}
+
+	if cycle := graph.findCycle(); cycle != nil {
+		names := make([]string, len(cycle))
+		for i, step := range cycle {
+			names[i] = graph.ids[step]
+		}
+		return nil, &graphError{step: cycle[0], message: "dependency cycle: " + strings.Join(names, " -> ")}
+	}
+
+	return graph, nil
+}
+
+// findCycle returns the steps forming a dependency cycle, with the first
+// step repeated at the end, or nil when the graph is acyclic
+func (g *stepGraph) findCycle() []int {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(g.ids))
+	var path []int
+
+	var visit func(step int) []int
+	visit = func(step int) []int {
+		state[step] = visiting
+		path = append(path, step)
+		for _, dependency := range g.needs[step] {
+			switch state[dependency] {
+			case visiting:
+				for i, s := range path {
+					if s == dependency {
+						return append(append([]int{}, path[i:]...), dependency)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dependency); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[step] = visited
+		return nil
+	}
+
+	for step := range g.ids {
+		if state[step] == unvisited {
+			if cycle := visit(step); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// dependsOn reports whether step waits, directly or indirectly, for target
+func (g *stepGraph) dependsOn(step, target int) bool {
+	seen := make([]bool, len(g.ids))
+	pending := append([]int{}, g.needs[step]...)
+	for len(pending) > 0 {
+		current := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if current == target {
+			return true
+		}
+		if !seen[current] {
+			seen[current] = true
+			pending = append(pending, g.needs[current]...)
+		}
+	}
+	return false
+}
@@ -0,0 +1,436 @@
+package automation
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Workflow expressions appear inside ${{ ... }} in commands, arguments and
+// environment values, and on their own in step if: fields and trigger
+// conditions. They support:
+//
+//	steps.<id>.outputs.<name>   output written by a step to $K3SS_OUTPUT
+//	steps.<id>.result           success, failure or skipped
+//	env.<NAME>                  process and workflow environment
+//	git.branch, git.sha         current repository state
+//	inputs.<name>               input of a template, passed by uses: ... with:
+//	'text', "text", 42, true    literals
+//	== != && || ! ( )           comparison and logic
+//	contains(a, b), startsWith(a, b), endsWith(a, b)
+//
+// Every value is a string; "", "false" and "0" are false, anything else is true.
+
+// exprContext holds the values expressions can refer to
+type exprContext struct {
+	env   map[string]string
+	git   map[string]string
+	steps map[string]*stepContext
+	scope *stepScope // template scope of the step being rendered; nil at the top level
+}
+
+// stepContext is what later steps can see of a finished step
+type stepContext struct {
+	result  string
+	outputs map[string]string
+}
+
+// expr is a parsed expression
+type expr interface {
+	eval(ctx *exprContext) string
+}
+
+// template is a string with embedded ${{ }} expressions
+type template struct {
+	literals []string // len(literals) == len(exprs)+1
+	exprs    []expr
+}
+
+// exprFunctions maps function names to implementations taking two arguments
+var exprFunctions = map[string]func(a, b string) bool{
+	"contains":   strings.Contains,
+	"startsWith": strings.HasPrefix,
+	"endsWith":   strings.HasSuffix,
+}
+
+// gitFields lists the keys available under git.
+var gitFields = []string{"branch", "sha"}
+
+// parseTemplate parses a string containing ${{ }} expressions
+func parseTemplate(s string) (*template, error) {
+	t := &template{}
+	for {
+		start := strings.Index(s, "${{")
+		if start < 0 {
+			t.literals = append(t.literals, s)
+			return t, nil
+		}
+		end := strings.Index(s[start:], "}}")
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated expression %q", s[start:])
+		}
+
+		e, err := parseExpr(s[start+3 : start+end])
+		if err != nil {
+			return nil, err
+		}
+		t.literals = append(t.literals, s[:start])
+		t.exprs = append(t.exprs, e)
+		s = s[start+end+2:]
+	}
+}
+
+// render evaluates every expression in the template
+func (t *template) render(ctx *exprContext) string {
+	var b strings.Builder
+	for i, e := range t.exprs {
+		b.WriteString(t.literals[i])
+		b.WriteString(e.eval(ctx))
+	}
+	b.WriteString(t.literals[len(t.literals)-1])
+	return b.String()
+}
+
+// refs returns every reference used in the template
+func (t *template) refs() [][]string {
+	var refs [][]string
+	for _, e := range t.exprs {
+		refs = append(refs, exprRefs(e)...)
+	}
+	return refs
+}
+
+// parseCondition parses an if: or trigger condition, with or without ${{ }}
+func parseCondition(s string) (expr, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "${{") && strings.HasSuffix(s, "}}") && strings.Count(s, "${{") == 1 {
+		s = s[3 : len(s)-2]
+	}
+	return parseExpr(s)
+}
+
+// truthy reports whether an expression value counts as true
+func truthy(value string) bool {
+	return value != "" && value != "false" && value != "0"
+}
+
+func boolString(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+// Expression nodes
+
+type literalExpr struct{ value string }
+
+type refExpr struct{ path []string }
+
+type notExpr struct{ operand expr }
+
+type binaryExpr struct {
+	op          string
+	left, right expr
+}
+
+type callExpr struct {
+	name string
+	args []expr
+}
+
+func (e literalExpr) eval(ctx *exprContext) string { return e.value }
+
+func (e refExpr) eval(ctx *exprContext) string {
+	switch e.path[0] {
+	case "env":
+		return ctx.env[e.path[1]]
+	case "git":
+		return ctx.git[e.path[1]]
+	case "inputs":
+		return ctx.input(e.path[1])
+	case "steps":
+		step := ctx.steps[ctx.scope.idPrefix()+e.path[1]]
+		if step == nil {
+			return ""
+		}
+		if e.path[2] == "result" {
+			return step.result
+		}
+		return step.outputs[e.path[3]]
+	}
+	return ""
+}
+
+func (e notExpr) eval(ctx *exprContext) string {
+	return boolString(!truthy(e.operand.eval(ctx)))
+}
+
+func (e binaryExpr) eval(ctx *exprContext) string {
+	switch e.op {
+	case "&&":
+		return boolString(truthy(e.left.eval(ctx)) && truthy(e.right.eval(ctx)))
+	case "||":
+		return boolString(truthy(e.left.eval(ctx)) || truthy(e.right.eval(ctx)))
+	case "==":
+		return boolString(e.left.eval(ctx) == e.right.eval(ctx))
+	default:
+		return boolString(e.left.eval(ctx) != e.right.eval(ctx))
+	}
+}
+
+func (e callExpr) eval(ctx *exprContext) string {
+	return boolString(exprFunctions[e.name](e.args[0].eval(ctx), e.args[1].eval(ctx)))
+}
+
+// exprRefs collects the references used by an expression
+func exprRefs(e expr) [][]string {
+	switch e := e.(type) {
+	case refExpr:
+		return [][]string{e.path}
+	case notExpr:
+		return exprRefs(e.operand)
+	case binaryExpr:
+		return append(exprRefs(e.left), exprRefs(e.right)...)
+	case callExpr:
+		var refs [][]string
+		for _, arg := range e.args {
+			refs = append(refs, exprRefs(arg)...)
+		}
+		return refs
+	}
+	return nil
+}
+
+// checkRef validates the shape of a reference such as steps.build.outputs.version
+func checkRef(path []string) error {
+	ref := strings.Join(path, ".")
+	switch path[0] {
+	case "env":
+		if len(path) != 2 {
+			return fmt.Errorf("invalid reference %q (expected env.NAME)", ref)
+		}
+	case "git":
+		if len(path) != 2 || !containsString(gitFields, path[1]) {
+			return fmt.Errorf("invalid reference %q (expected git.%s)", ref, strings.Join(gitFields, " or git."))
+		}
+	case "inputs":
+		if len(path) != 2 {
+			return fmt.Errorf("invalid reference %q (expected inputs.NAME)", ref)
+		}
+	case "steps":
+		valid := (len(path) == 3 && path[2] == "result") || (len(path) == 4 && path[2] == "outputs")
+		if !valid {
+			return fmt.Errorf("invalid reference %q (expected steps.ID.outputs.NAME or steps.ID.result)", ref)
+		}
+	default:
+		return fmt.Errorf("unknown context %q in %q (expected steps, env, git or inputs)", path[0], ref)
+	}
+	return nil
+}
+
+// Parser
+
+type exprToken struct {
+	kind  string // "ident", "string", "number", "op", "eof"
+	value string
+}
+
+type exprParser struct {
+	source string
+	tokens []exprToken
+	pos    int
+}
+
+// parseExpr parses a single expression
+func parseExpr(source string) (expr, error) {
+	tokens, err := tokenizeExpr(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", strings.TrimSpace(source), err)
+	}
+
+	p := &exprParser{source: source, tokens: tokens}
+	e, err := p.parseOr()
+	if err == nil && p.peek().kind != "eof" {
+		err = fmt.Errorf("unexpected %q", p.peek().value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", strings.TrimSpace(source), err)
+	}
+	return e, nil
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	token := p.tokens[p.pos]
+	if token.kind != "eof" {
+		p.pos++
+	}
+	return token
+}
+
+func (p *exprParser) accept(op string) bool {
+	if token := p.peek(); token.kind == "op" && token.value == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.accept("||") {
+		var right expr
+		right, err = p.parseAnd()
+		left = binaryExpr{op: "||", left: left, right: right}
+	}
+	return left, err
+}
+
+func (p *exprParser) parseAnd() (expr, error) {
+	left, err := p.parseComparison()
+	for err == nil && p.accept("&&") {
+		var right expr
+		right, err = p.parseComparison()
+		left = binaryExpr{op: "&&", left: left, right: right}
+	}
+	return left, err
+}
+
+func (p *exprParser) parseComparison() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!="} {
+		if p.accept(op) {
+			right, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			return binaryExpr{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (expr, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (expr, error) {
+	token := p.next()
+	switch token.kind {
+	case "string", "number":
+		return literalExpr{value: token.value}, nil
+	case "op":
+		if token.value == "(" {
+			e, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if !p.accept(")") {
+				return nil, fmt.Errorf("missing )")
+			}
+			return e, nil
+		}
+		return nil, fmt.Errorf("unexpected %q", token.value)
+	case "ident":
+		if token.value == "true" || token.value == "false" {
+			return literalExpr{value: token.value}, nil
+		}
+		if p.accept("(") {
+			return p.parseCall(token.value)
+		}
+		path := strings.Split(token.value, ".")
+		if err := checkRef(path); err != nil {
+			return nil, err
+		}
+		return refExpr{path: path}, nil
+	default:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+}
+
+func (p *exprParser) parseCall(name string) (expr, error) {
+	if _, ok := exprFunctions[name]; !ok {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+
+	call := callExpr{name: name}
+	for !p.accept(")") {
+		if len(call.args) > 0 && !p.accept(",") {
+			return nil, fmt.Errorf("expected , or ) in call to %s", name)
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+	}
+	if len(call.args) != 2 {
+		return nil, fmt.Errorf("%s takes 2 arguments, got %d", name, len(call.args))
+	}
+	return call, nil
+}
+
+// tokenizeExpr splits an expression into tokens
+func tokenizeExpr(source string) ([]exprToken, error) {
+	var tokens []exprToken
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'' || r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, exprToken{kind: "string", value: string(runes[i+1 : end])})
+			i = end + 1
+		case unicode.IsDigit(r):
+			end := i
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, exprToken{kind: "number", value: string(runes[i:end])})
+			i = end
+		case unicode.IsLetter(r) || r == '_':
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || strings.ContainsRune("_-.", runes[end])) {
+				end++
+			}
+			tokens = append(tokens, exprToken{kind: "ident", value: string(runes[i:end])})
+			i = end
+		default:
+			if i+1 < len(runes) {
+				if op := string(runes[i : i+2]); op == "==" || op == "!=" || op == "&&" || op == "||" {
+					tokens = append(tokens, exprToken{kind: "op", value: op})
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("!(),", r) {
+				return nil, fmt.Errorf("unexpected character %q", r)
+			}
+			tokens = append(tokens, exprToken{kind: "op", value: string(r)})
+			i++
+		}
+	}
+	return append(tokens, exprToken{kind: "eof"}), nil
+}
@@ -0,0 +1,166 @@
+package automation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/git"
+)
+
+// EventEnv and HookArgsEnv tell a workflow started by a git hook which hook
+// ran and with what arguments, one per line
+const (
+	EventEnv    = "K3SS_EVENT"
+	HookArgsEnv = "K3SS_HOOK_ARGS"
+)
+
+// gitHooks lists the client-side hooks a git_hook workflow can bind to
+var gitHooks = []string{
+	"applypatch-msg", "pre-applypatch", "post-applypatch",
+	"pre-commit", "pre-merge-commit", "prepare-commit-msg", "commit-msg", "post-commit",
+	"pre-rebase", "post-checkout", "post-merge", "pre-push", "post-rewrite",
+}
+
+// eventAliases maps short event names to the hook they run from
+var eventAliases = map[string]string{
+	"commit":   "pre-commit",
+	"push":     "pre-push",
+	"merge":    "post-merge",
+	"checkout": "post-checkout",
+}
+
+// ParseEvent splits a git_hook event such as "pre-commit" or "push:main" into
+// the hook it runs from and an optional branch it is limited to
+func ParseEvent(event string) (hook, branch string, err error) {
+	hook, branch, _ = strings.Cut(strings.TrimSpace(event), ":")
+	if alias, ok := eventAliases[hook]; ok {
+		hook = alias
+	}
+	if !containsString(gitHooks, hook) {
+		return "", "", fmt.Errorf("unknown git hook event %q (expected one of %s, or %s)",
+			event, strings.Join(gitHooks, ", "), strings.Join(eventAliasNames(), ", "))
+	}
+	return hook, branch, nil
+}
+
+// ResolveHooks maps events such as "push" or "pre-commit:main" to the git
+// hooks they run from, each once in the order first named. A hook runs on
+// every branch, so branch suffixes are dropped; the events that had one are
+// returned as well.
+func ResolveHooks(events []string) (hooks, branched []string, err error) {
+	for _, event := range events {
+		hook, branch, err := ParseEvent(event)
+		if err != nil {
+			return nil, nil, err
+		}
+		if branch != "" {
+			branched = append(branched, event)
+		}
+		if !containsString(hooks, hook) {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks, branched, nil
+}
+
+// eventAliasNames returns the short event names, sorted
+func eventAliasNames() []string {
+	names := make([]string, 0, len(eventAliases))
+	for name := range eventAliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HookBindings maps each git hook to the git_hook workflows bound to it
+func (a *AutomationService) HookBindings() map[string][]string {
+	bindings := make(map[string][]string)
+	for _, workflow := range a.workflows {
+		if workflow.Trigger.Type != "git_hook" {
+			continue
+		}
+		for _, event := range workflow.Trigger.Events {
+			hook, _, err := ParseEvent(event)
+			if err == nil && !containsString(bindings[hook], workflow.Name) {
+				bindings[hook] = append(bindings[hook], workflow.Name)
+			}
+		}
+	}
+	for hook := range bindings {
+		sort.Strings(bindings[hook])
+	}
+	return bindings
+}
+
+// TriggerEvent runs, in name order, every git_hook workflow bound to the hook
+// an event such as "pre-commit" or "push" runs from, calling onResult after
+// each. Events limited to a branch only fire on that branch: for pre-push, a
+// branch being pushed according to input; otherwise the current branch.
+// Cancelling ctx interrupts the running workflow.
+func (a *AutomationService) TriggerEvent(ctx context.Context, event string, args []string, input string, onResult func(name string, result *WorkflowResult, err error)) error {
+	hook, _, err := ParseEvent(event)
+	if err != nil {
+		return err
+	}
+
+	branches := eventBranches(a.projectPath, hook, input)
+	var names []string
+	for _, workflow := range a.workflows {
+		if workflow.Trigger.Type == "git_hook" && firesOn(workflow.Trigger.Events, hook, branches) {
+			names = append(names, workflow.Name)
+		}
+	}
+	sort.Strings(names)
+
+	env := map[string]string{
+		EventEnv:    hook,
+		HookArgsEnv: strings.Join(args, "\n"),
+	}
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		result, err := a.RunWorkflow(ctx, name, RunOptions{Trigger: "git_hook:" + hook, Env: env})
+		onResult(name, result, err)
+	}
+	return nil
+}
+
+// firesOn reports whether any of a workflow's events matches the hook on one
+// of the branches
+func firesOn(events []string, hook string, branches []string) bool {
+	for _, event := range events {
+		eventHook, branch, err := ParseEvent(event)
+		if err != nil || eventHook != hook {
+			continue
+		}
+		if branch == "" || containsString(branches, branch) {
+			return true
+		}
+	}
+	return false
+}
+
+// eventBranches returns the branches an event applies to. pre-push receives
+// "<local ref> <local sha> <remote ref> <remote sha>" lines on stdin.
+func eventBranches(projectPath, hook, input string) []string {
+	var branches []string
+	if hook == "pre-push" {
+		for _, line := range strings.Split(input, "\n") {
+			if fields := strings.Fields(line); len(fields) == 4 && strings.HasPrefix(fields[2], "refs/heads/") {
+				branches = append(branches, strings.TrimPrefix(fields[2], "refs/heads/"))
+			}
+		}
+		if len(branches) > 0 {
+			return branches
+		}
+	}
+
+	if branch, err := git.NewGitService(projectPath).GetCurrentBranch(); err == nil {
+		branches = append(branches, branch)
+	}
+	return branches
+}
@@ -0,0 +1,232 @@
+package automation
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// alwaysIgnored lists directories that are never watched or processed
+var alwaysIgnored = []string{".git", ".k3ss-ai"}
+
+// projectIgnoreFiles are the ignore files honoured when watching and when
+// selecting files for batch operations
+var projectIgnoreFiles = []string{".gitignore", ".k3ss-aiignore"}
+
+// IgnoreMatcher applies .gitignore-style rules found in a project. Rule files
+// are read lazily from each directory as paths below it are checked, and
+// rules in deeper directories take precedence, as in git.
+type IgnoreMatcher struct {
+	root    string
+	files   []string
+	exclude []ignoreRule // added by Exclude, applied after the files' rules
+
+	mu    sync.Mutex
+	rules map[string][]ignoreRule // rules by directory, relative to root
+}
+
+// ignoreRule is one line of an ignore file
+type ignoreRule struct {
+	base     string   // directory holding the ignore file, relative to root
+	segments []string // pattern split on "/"
+	anchored bool     // pattern contains a slash, so it matches from base
+	negate   bool
+	dirOnly  bool
+}
+
+// NewIgnoreMatcher returns a matcher for the project at root that reads the
+// named ignore files, e.g. ".gitignore", from every directory
+func NewIgnoreMatcher(root string, files ...string) *IgnoreMatcher {
+	return &IgnoreMatcher{
+		root:  root,
+		files: files,
+		rules: make(map[string][]ignoreRule),
+	}
+}
+
+// newProjectIgnoreMatcher returns a matcher for the project's ignore files
+func newProjectIgnoreMatcher(root string) *IgnoreMatcher {
+	return NewIgnoreMatcher(root, projectIgnoreFiles...)
+}
+
+// Exclude adds .gitignore-style patterns, such as "node_modules" or
+// "docs/*.md", that take precedence over the rules from ignore files
+func (m *IgnoreMatcher) Exclude(patterns ...string) {
+	for _, pattern := range patterns {
+		if rule, ok := parseIgnoreRule(pattern, ""); ok {
+			m.exclude = append(m.exclude, rule)
+		}
+	}
+}
+
+// Ignored reports whether the slash-separated path rel, relative to the
+// project root, is ignored. A path inside an ignored directory is ignored.
+func (m *IgnoreMatcher) Ignored(rel string, isDir bool) bool {
+	rel = path.Clean(filepath.ToSlash(rel))
+	if rel == "." || rel == "" {
+		return false
+	}
+
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		last := i == len(parts)-1
+		if m.ignoredEntry(strings.Join(parts[:i+1], "/"), !last || isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoredEntry checks a single path against the rules of its ancestors,
+// without considering whether a parent directory is ignored
+func (m *IgnoreMatcher) ignoredEntry(rel string, isDir bool) bool {
+	name := path.Base(rel)
+	if isDir && containsString(alwaysIgnored, name) {
+		return true
+	}
+
+	ignored := false
+	dir := ""
+	for _, part := range append([]string{""}, strings.Split(path.Dir(rel), "/")...) {
+		if part != "" && part != "." {
+			dir = path.Join(dir, part)
+		}
+		for _, rule := range m.rulesFor(dir) {
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if rule.matches(rel) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	for _, rule := range m.exclude {
+		if (!rule.dirOnly || isDir) && rule.matches(rel) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// rulesFor returns the rules from the ignore files in dir, reading them once
+func (m *IgnoreMatcher) rulesFor(dir string) []ignoreRule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rules, ok := m.rules[dir]; ok {
+		return rules
+	}
+
+	var rules []ignoreRule
+	for _, name := range m.files {
+		rules = append(rules, readIgnoreFile(filepath.Join(m.root, filepath.FromSlash(dir), name), dir)...)
+	}
+	m.rules[dir] = rules
+	return rules
+}
+
+// readIgnoreFile parses an ignore file; a missing file has no rules
+func readIgnoreFile(file, base string) []ignoreRule {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text(), base); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// parseIgnoreRule parses one line of an ignore file
+func parseIgnoreRule(line, base string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	rule.segments = strings.Split(line, "/")
+	return rule, true
+}
+
+// matches reports whether the rule applies to rel, a path relative to the root
+func (r ignoreRule) matches(rel string) bool {
+	if r.base != "" {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+		rel = strings.TrimPrefix(rel, r.base+"/")
+	}
+
+	if !r.anchored {
+		return matchSegments(r.segments, []string{path.Base(rel)})
+	}
+	return matchSegments(r.segments, strings.Split(rel, "/"))
+}
+
+// MatchGlob matches a slash-separated relative path against a glob pattern.
+// "**" matches any number of directories. A pattern without a slash matches
+// the file name at any depth, so "*.go" matches "cmd/main.go".
+func MatchGlob(pattern, rel string) bool {
+	rel = path.Clean(filepath.ToSlash(rel))
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	if !strings.Contains(pattern, "/") {
+		return matchSegments([]string{pattern}, []string{path.Base(rel)})
+	}
+	return matchSegments(strings.Split(strings.TrimPrefix(pattern, "/"), "/"), strings.Split(rel, "/"))
+}
+
+// matchSegments matches path segments against pattern segments, where a
+// "**" segment matches zero or more path segments
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			if len(rest) == 0 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
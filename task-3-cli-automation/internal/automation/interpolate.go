@@ -0,0 +1,212 @@
+package automation
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/git"
+)
+
+// OutputEnv names the file a step writes name=value lines to in order to
+// publish outputs as steps.<id>.outputs.<name>
+const OutputEnv = "K3SS_OUTPUT"
+
+// newExprContext builds the expression context for a workflow run from the
+// process environment, the triggering event, the workflow environment and the
+// repository state
+func (a *AutomationService) newExprContext(workflow *Workflow, event map[string]string) (*exprContext, error) {
+	ctx := &exprContext{
+		env:   make(map[string]string),
+		git:   make(map[string]string),
+		steps: make(map[string]*stepContext),
+	}
+	if len(workflow.Inputs) > 0 {
+		// Run on its own, a template sees the defaults of its inputs
+		inputs, err := templateInputs(workflow, nil)
+		if err != nil {
+			return nil, err
+		}
+		ctx.scope = &stepScope{inputs: inputs}
+	}
+	for _, entry := range os.Environ() {
+		if name, value, ok := strings.Cut(entry, "="); ok {
+			ctx.env[name] = value
+		}
+	}
+	for name, value := range event {
+		ctx.env[name] = value
+	}
+
+	gitService := git.NewGitService(a.projectPath)
+	if gitService.IsGitRepo() {
+		if branch, err := gitService.GetCurrentBranch(); err == nil {
+			ctx.git["branch"] = branch
+		}
+		if commits, err := gitService.GetCommitHistory(1); err == nil && len(commits) > 0 {
+			ctx.git["sha"] = commits[0].Hash
+		}
+	}
+
+	// Workflow values may refer to the process environment but not to each other
+	rendered := make(map[string]string, len(workflow.Environment))
+	for name, value := range workflow.Environment {
+		t, err := parseTemplate(value)
+		if err != nil {
+			return nil, fmt.Errorf("environment %s: %w", name, err)
+		}
+		rendered[name] = t.render(ctx)
+	}
+	for name, value := range rendered {
+		ctx.env[name] = value
+	}
+	return ctx, nil
+}
+
+// conditionsMet evaluates the trigger conditions of a workflow
+func conditionsMet(workflow *Workflow, ctx *exprContext) (bool, error) {
+	for _, condition := range workflow.Trigger.Conditions {
+		e, err := parseCondition(condition)
+		if err != nil {
+			return false, err
+		}
+		if !truthy(e.eval(ctx)) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// renderStep evaluates the step's if: condition and interpolates its command,
+// arguments and environment. It reports false when the condition, or that of
+// a uses: step it was taken from, is not met.
+func renderStep(step WorkflowStep, parent *exprContext) (WorkflowStep, bool, error) {
+	for _, guard := range step.guards {
+		guardCtx := *parent
+		if guard.scope != nil {
+			guardCtx.scope = guard.scope
+		}
+		condition, err := parseCondition(guard.condition)
+		if err != nil {
+			return step, false, err
+		}
+		if !truthy(condition.eval(&guardCtx)) {
+			return step, false, nil
+		}
+	}
+
+	ctx := parent
+	if step.scope != nil {
+		scoped := *parent
+		scoped.scope = step.scope
+		ctx = &scoped
+	}
+
+	if step.If != "" {
+		condition, err := parseCondition(step.If)
+		if err != nil {
+			return step, false, err
+		}
+		if !truthy(condition.eval(ctx)) {
+			return step, false, nil
+		}
+	}
+
+	render := func(value string) (string, error) {
+		t, err := parseTemplate(value)
+		if err != nil {
+			return "", err
+		}
+		return t.render(ctx), nil
+	}
+
+	rendered := step
+	var err error
+	if rendered.Command, err = render(step.Command); err != nil {
+		return step, false, err
+	}
+	rendered.Args = make([]string, len(step.Args))
+	for i, arg := range step.Args {
+		if rendered.Args[i], err = render(arg); err != nil {
+			return step, false, err
+		}
+	}
+	rendered.Environment = make(map[string]string, len(step.Environment))
+	for name, value := range step.Environment {
+		if rendered.Environment[name], err = render(value); err != nil {
+			return step, false, err
+		}
+	}
+	return rendered, true, nil
+}
+
+// readOutputs parses a $K3SS_OUTPUT file. Each line is name=value; a value
+// spanning several lines is written as name<<DELIMITER, the lines, then DELIMITER.
+func readOutputs(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	outputs := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if name, delimiter, ok := strings.Cut(line, "<<"); ok && !strings.Contains(name, "=") {
+			var value []string
+			closed := false
+			for scanner.Scan() {
+				if scanner.Text() == delimiter {
+					closed = true
+					break
+				}
+				value = append(value, scanner.Text())
+			}
+			if !closed {
+				return nil, fmt.Errorf("output %s is missing its closing %s", name, delimiter)
+			}
+			outputs[name] = strings.Join(value, "\n")
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid output line %q (expected name=value)", line)
+		}
+		outputs[name] = value
+	}
+	return outputs, scanner.Err()
+}
+
+// checkStepRefs verifies that every steps.<id> reference names a step that
+// is guaranteed to have finished, i.e. one the step depends on. A step past
+// the last one stands for a handler, which runs after all of them.
+func checkStepRefs(refs [][]string, graph *stepGraph, step int) error {
+	for _, ref := range refs {
+		if ref[0] != "steps" {
+			continue
+		}
+		target := -1
+		for i, id := range graph.ids {
+			if id == ref[1] {
+				target = i
+			}
+		}
+		if target < 0 {
+			return fmt.Errorf("%s refers to unknown step %q", strings.Join(ref, "."), ref[1])
+		}
+		if step < 0 {
+			return fmt.Errorf("%s cannot be used here; no steps have run yet", strings.Join(ref, "."))
+		}
+		if step < len(graph.ids) && !graph.dependsOn(step, target) {
+			return fmt.Errorf("%s refers to step %q, which step %q does not need", strings.Join(ref, "."), ref[1], graph.ids[step])
+		}
+	}
+	return nil
+}
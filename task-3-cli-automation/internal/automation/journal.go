@@ -0,0 +1,419 @@
+package automation
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Journals of operations that change files live in
+// .k3ss-ai/journal/<run-id>/, holding journal.json with each file's
+// original and new content hashes and backups/<file> with its original
+// content, so the changes can be undone. While the operation runs, files
+// are recorded by appending to journal.log, which Finish folds into
+// journal.json; the log of a run that never finished is read back with it.
+
+// JournalDir holds the journals, relative to the project
+const JournalDir = ".k3ss-ai/journal"
+
+// Journal statuses
+const (
+	JournalRunning    = "running"
+	JournalDone       = "done"
+	JournalRolledBack = "rolled_back" // restored because an all-or-nothing run failed
+	JournalUndone     = "undone"
+)
+
+// Journal records the files an operation changed
+type Journal struct {
+	ID        string         `json:"id"`
+	Operation string         `json:"operation"`
+	Status    string         `json:"status"`
+	StartTime time.Time      `json:"start_time"`
+	Files     []JournalEntry `json:"files"`
+
+	projectPath string
+	mu          sync.Mutex
+	index       map[string]int  // position of each tracked file in Files, -1 while being backed up
+	dropped     map[string]bool // tracked files that were not changed, left in Files until compact
+	log         *os.File        // journal.log, open while the operation runs
+}
+
+// JournalEntry records one changed file
+type JournalEntry struct {
+	File         string      `json:"file"` // relative to the project
+	Created      bool        `json:"created,omitempty"`
+	Mode         os.FileMode `json:"mode,omitempty"`
+	OriginalHash string      `json:"original_hash,omitempty"` // sha256 of the content before the change
+	Hash         string      `json:"hash,omitempty"`          // sha256 after it; empty while the change is in progress
+}
+
+// journalRecord is one line of journal.log: a file as tracked or settled,
+// or dropped because it was not changed after all
+type journalRecord struct {
+	JournalEntry
+	Dropped bool `json:"dropped,omitempty"`
+}
+
+// ConflictError reports files changed since the journaled operation, which
+// undoing would overwrite
+type ConflictError struct {
+	Files []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("changed since the run: %s", strings.Join(e.Files, ", "))
+}
+
+// NewJournal starts a journal for an operation on the project's files
+func NewJournal(projectPath, operation string) (*Journal, error) {
+	journal := &Journal{
+		ID:          newRunID(time.Now()),
+		Operation:   operation,
+		Status:      JournalRunning,
+		StartTime:   time.Now(),
+		Files:       []JournalEntry{},
+		projectPath: projectPath,
+		index:       make(map[string]int),
+		dropped:     make(map[string]bool),
+	}
+	if err := os.MkdirAll(journal.dir(), 0755); err != nil {
+		return nil, err
+	}
+	if err := journal.save(); err != nil {
+		return nil, err
+	}
+	log, err := os.OpenFile(journal.logPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	journal.log = log
+	return journal, nil
+}
+
+// LoadJournal reads the journal of a run
+func LoadJournal(projectPath, id string) (*Journal, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return nil, fmt.Errorf("invalid run id %q", id)
+	}
+	data, err := os.ReadFile(filepath.Join(projectPath, JournalDir, id, "journal.json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no journal for run %s", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	journal := &Journal{projectPath: projectPath}
+	if err := json.Unmarshal(data, journal); err != nil {
+		return nil, fmt.Errorf("journal of run %s: %w", id, err)
+	}
+	if err := journal.replay(); err != nil {
+		return nil, fmt.Errorf("journal of run %s: %w", id, err)
+	}
+	return journal, nil
+}
+
+// replay applies the journal.log left by a run that did not finish
+func (j *Journal) replay() error {
+	j.index, j.dropped = make(map[string]int), make(map[string]bool)
+	for i, entry := range j.Files {
+		j.index[entry.File] = i
+	}
+	file, err := os.Open(j.logPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			break // a line cut short by the interruption
+		}
+		j.dropped[record.File] = record.Dropped
+		if i, ok := j.index[record.File]; ok {
+			j.Files[i] = record.JournalEntry
+		} else {
+			j.index[record.File] = len(j.Files)
+			j.Files = append(j.Files, record.JournalEntry)
+		}
+	}
+	j.compact()
+	return scanner.Err()
+}
+
+// compact removes the files that were not changed from Files
+func (j *Journal) compact() {
+	files := j.Files[:0]
+	for _, entry := range j.Files {
+		if j.dropped[entry.File] {
+			delete(j.index, entry.File)
+			continue
+		}
+		j.index[entry.File] = len(files)
+		files = append(files, entry)
+	}
+	j.Files = files
+	j.dropped = make(map[string]bool)
+}
+
+// LoadJournals reads every journal of the project, newest first
+func LoadJournals(projectPath string) ([]*Journal, error) {
+	entries, err := os.ReadDir(filepath.Join(projectPath, JournalDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var journals []*Journal
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		journal, err := LoadJournal(projectPath, entry.Name())
+		if err != nil {
+			continue
+		}
+		journals = append(journals, journal)
+	}
+	sort.Slice(journals, func(i, j int) bool { return journals[i].StartTime.After(journals[j].StartTime) })
+	return journals, nil
+}
+
+// dir returns the journal's directory
+func (j *Journal) dir() string {
+	return filepath.Join(j.projectPath, JournalDir, j.ID)
+}
+
+// backup returns where the original content of a file is kept
+func (j *Journal) backup(file string) string {
+	return filepath.Join(j.dir(), "backups", file)
+}
+
+// logPath returns the path of journal.log
+func (j *Journal) logPath() string {
+	return filepath.Join(j.dir(), "journal.log")
+}
+
+// save writes journal.json, replacing the previous one in one step. Once
+// the operation has stopped running, journal.log is folded into it and
+// removed.
+func (j *Journal) save() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(j.dir(), "journal.json")
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+	if j.log == nil {
+		if err := os.Remove(j.logPath()); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// record appends a line to journal.log
+func (j *Journal) record(record journalRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = j.log.Write(append(data, '\n'))
+	return err
+}
+
+// hashFile returns the sha256 of a file's content, or "" if it does not exist
+func hashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Track backs up a project-relative file before it is changed. A file
+// that does not exist yet is recorded as created. Tracking a file twice
+// keeps the first backup. Only recording the file holds the journal's lock,
+// so files are backed up concurrently.
+func (j *Journal) Track(file string) error {
+	j.mu.Lock()
+	if _, ok := j.index[file]; ok {
+		j.mu.Unlock()
+		return nil
+	}
+	j.index[file] = -1
+	j.mu.Unlock()
+
+	entry, err := j.backUp(file)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err != nil {
+		delete(j.index, file)
+		return err
+	}
+	j.index[file] = len(j.Files)
+	j.Files = append(j.Files, entry)
+	return j.record(journalRecord{JournalEntry: entry})
+}
+
+// backUp copies a file's original content into the journal
+func (j *Journal) backUp(file string) (JournalEntry, error) {
+	entry := JournalEntry{File: file}
+	path := filepath.Join(j.projectPath, file)
+	info, err := os.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+		entry.Created = true
+	case err != nil:
+		return entry, err
+	default:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return entry, err
+		}
+		backup := j.backup(file)
+		if err := os.MkdirAll(filepath.Dir(backup), 0755); err != nil {
+			return entry, err
+		}
+		if err := os.WriteFile(backup, data, 0644); err != nil {
+			return entry, fmt.Errorf("backing up %s: %w", file, err)
+		}
+		sum := sha256.Sum256(data)
+		entry.Mode, entry.OriginalHash = info.Mode().Perm(), hex.EncodeToString(sum[:])
+	}
+	return entry, nil
+}
+
+// Settle records the new content of a tracked file, dropping it from the
+// journal if it was not changed after all
+func (j *Journal) Settle(file string) error {
+	hash, err := hashFile(filepath.Join(j.projectPath, file))
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	i, ok := j.index[file]
+	if !ok || i < 0 {
+		return nil
+	}
+	entry := &j.Files[i]
+	if hash == entry.OriginalHash {
+		os.Remove(j.backup(file))
+		j.dropped[file] = true
+		return j.record(journalRecord{JournalEntry: *entry, Dropped: true})
+	}
+	entry.Hash = hash
+	return j.record(journalRecord{JournalEntry: *entry})
+}
+
+// Finish records the operation's outcome. A journal of an operation that
+// changed nothing is removed and Finish reports false.
+func (j *Journal) Finish(status string) (bool, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.log != nil {
+		if err := j.log.Close(); err != nil {
+			return false, err
+		}
+		j.log = nil
+	}
+	j.compact()
+	if len(j.Files) == 0 {
+		return false, os.RemoveAll(j.dir())
+	}
+	j.Status = status
+	return true, j.save()
+}
+
+// Undo restores every file the journal recorded, newest change first:
+// changed files get their original content back and created ones are
+// removed. Unless force is set, nothing is restored if a file was changed
+// again since, reported as a *ConflictError.
+func (j *Journal) Undo(force bool) ([]string, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.Status == JournalUndone || j.Status == JournalRolledBack {
+		return nil, fmt.Errorf("run %s was already %s", j.ID, strings.ReplaceAll(j.Status, "_", " "))
+	}
+
+	if !force {
+		var conflicts []string
+		for _, entry := range j.Files {
+			hash, err := hashFile(filepath.Join(j.projectPath, entry.File))
+			if err != nil {
+				return nil, err
+			}
+			if entry.Hash != "" && hash != entry.Hash {
+				conflicts = append(conflicts, entry.File)
+			}
+		}
+		if len(conflicts) > 0 {
+			return nil, &ConflictError{Files: conflicts}
+		}
+	}
+	return j.restore(JournalUndone)
+}
+
+// Rollback restores every file the journal recorded, as Undo does, right
+// after an all-or-nothing operation failed
+func (j *Journal) Rollback() ([]string, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.restore(JournalRolledBack)
+}
+
+// restore puts back the original files and records status
+func (j *Journal) restore(status string) ([]string, error) {
+	j.compact()
+	var restored []string
+	for i := len(j.Files) - 1; i >= 0; i-- {
+		entry := j.Files[i]
+		path := filepath.Join(j.projectPath, entry.File)
+		if entry.Created {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return restored, err
+			}
+		} else {
+			data, err := os.ReadFile(j.backup(entry.File))
+			if err != nil {
+				return restored, fmt.Errorf("backup of %s: %w", entry.File, err)
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return restored, err
+			}
+			if err := os.WriteFile(path, data, entry.Mode); err != nil {
+				return restored, err
+			}
+			if err := os.Chmod(path, entry.Mode); err != nil {
+				return restored, err
+			}
+		}
+		restored = append(restored, entry.File)
+	}
+	j.Status = status
+	return restored, j.save()
+}
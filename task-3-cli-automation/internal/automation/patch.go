@@ -0,0 +1,258 @@
+package automation
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PatchDir holds the patches batch operations write for review, one per
+// source file at <PatchDir>/<file>.patch, relative to the project
+const PatchDir = ".k3ss-ai/patches"
+
+// patchContext is the number of unchanged lines around each hunk
+const patchContext = 3
+
+// fileLines splits content into lines, reporting whether it ends in a newline
+func fileLines(content string) ([]string, bool) {
+	if content == "" {
+		return nil, true
+	}
+	lines := strings.Split(content, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1], true
+	}
+	return lines, false
+}
+
+// joinLines is the inverse of fileLines
+func joinLines(lines []string, newline bool) string {
+	content := strings.Join(lines, "\n")
+	if newline && len(lines) > 0 {
+		content += "\n"
+	}
+	return content
+}
+
+// insertLines returns content with the lines of inserts added before the
+// 0-based line each is keyed by
+func insertLines(content string, inserts map[int][]string) string {
+	lines, newline := fileLines(content)
+	var result []string
+	for i, line := range lines {
+		result = append(result, inserts[i]...)
+		result = append(result, line)
+	}
+	result = append(result, inserts[len(lines)]...)
+	return joinLines(result, newline)
+}
+
+// patchEdit is one line of an edit script: ' ' keeps a line, '+' adds one
+type patchEdit struct {
+	op       byte
+	text     string
+	old, new int // 1-based line numbers on each side
+}
+
+// insertionPatch returns a unified diff, applicable with git apply or
+// patch -p1, adding the lines of inserts to content as insertLines does
+func insertionPatch(file, content string, inserts map[int][]string) string {
+	lines, newline := fileLines(content)
+
+	var edits []patchEdit
+	old, new := 1, 1
+	for i := 0; i <= len(lines); i++ {
+		for _, text := range inserts[i] {
+			edits = append(edits, patchEdit{'+', text, old, new})
+			new++
+		}
+		if i < len(lines) {
+			edits = append(edits, patchEdit{' ', lines[i], old, new})
+			old++
+			new++
+		}
+	}
+
+	var patch strings.Builder
+	file = filepath.ToSlash(file)
+	fmt.Fprintf(&patch, "--- a/%s\n+++ b/%s\n", file, file)
+	for start := 0; start < len(edits); {
+		// Find the next addition and grow the hunk while the following
+		// ones are close enough for their context to overlap
+		first := start
+		for first < len(edits) && edits[first].op != '+' {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+		last := first
+		for next := first + 1; next < len(edits) && next-last <= 2*patchContext; next++ {
+			if edits[next].op == '+' {
+				last = next
+			}
+		}
+		from := max(first-patchContext, start)
+		to := min(last+patchContext+1, len(edits))
+
+		oldCount, newCount := 0, 0
+		var body strings.Builder
+		for _, e := range edits[from:to] {
+			fmt.Fprintf(&body, "%c%s\n", e.op, e.text)
+			newCount++
+			if e.op == ' ' {
+				oldCount++
+				if !newline && e.old == len(lines) {
+					body.WriteString("\\ No newline at end of file\n")
+				}
+			}
+		}
+		oldStart := edits[from].old
+		if oldCount == 0 {
+			oldStart-- // an empty range names the line before it
+		}
+		fmt.Fprintf(&patch, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(edits[from].new, newCount))
+		patch.WriteString(body.String())
+		start = to
+	}
+	return patch.String()
+}
+
+// hunkRange formats the start and length of one side of a hunk
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return strconv.Itoa(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// hunkHeader matches the range line starting each hunk of a unified diff
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// applyPatch applies the hunks of a single-file unified diff to content.
+// Each hunk must match exactly, though it may have moved by a few lines.
+func applyPatch(content, patch string) (string, error) {
+	lines, newline := fileLines(content)
+	patchLines, _ := fileLines(patch)
+
+	var result []string
+	next, hunks := 0, 0 // next original line to copy, 0-based
+	for i := 0; i < len(patchLines); {
+		match := hunkHeader.FindStringSubmatch(patchLines[i])
+		i++
+		if match == nil {
+			continue
+		}
+		hunks++
+
+		var old, new []string
+		oldEOF, newEOF := false, false
+		for ; i < len(patchLines) && !strings.HasPrefix(patchLines[i], "@@"); i++ {
+			line := patchLines[i]
+			if strings.HasPrefix(line, "--- ") && i+1 < len(patchLines) && strings.HasPrefix(patchLines[i+1], "+++ ") {
+				break // the next file's header
+			}
+			if line == "" {
+				line = " " // blank context lines lose their space in some editors
+			}
+			switch line[0] {
+			case ' ':
+				old, new = append(old, line[1:]), append(new, line[1:])
+			case '-':
+				old = append(old, line[1:])
+			case '+':
+				new = append(new, line[1:])
+			case '\\':
+				switch patchLines[i-1][0] {
+				case '-':
+					oldEOF = true
+				case '+':
+					newEOF = true
+				default:
+					oldEOF, newEOF = true, true
+				}
+			default:
+				return "", fmt.Errorf("hunk %d: unexpected line %q", hunks, line)
+			}
+		}
+
+		start, _ := strconv.Atoi(match[1])
+		if len(old) > 0 {
+			start-- // the first old line, 0-based
+		}
+		at := findHunk(lines, old, start, next)
+		if at < 0 {
+			return "", fmt.Errorf("hunk %d does not apply at line %d", hunks, start+1)
+		}
+		result = append(result, lines[next:at]...)
+		result = append(result, new...)
+		next = at + len(old)
+		if next == len(lines) && (oldEOF || newEOF) {
+			newline = !newEOF
+		}
+	}
+	if hunks == 0 {
+		return "", fmt.Errorf("no hunks found in patch")
+	}
+	result = append(result, lines[next:]...)
+	return joinLines(result, newline), nil
+}
+
+// findHunk returns where the old lines of a hunk match lines, trying start
+// first and then moving outwards, without going back before floor
+func findHunk(lines, old []string, start, floor int) int {
+	matches := func(at int) bool {
+		if at < floor || at+len(old) > len(lines) {
+			return false
+		}
+		for i, line := range old {
+			if lines[at+i] != line {
+				return false
+			}
+		}
+		return true
+	}
+	for offset := 0; offset <= len(lines); offset++ {
+		if matches(start + offset) {
+			return start + offset
+		}
+		if offset > 0 && matches(start-offset) {
+			return start - offset
+		}
+	}
+	return -1
+}
+
+// patchPath returns where the patch for a project-relative file is kept
+func patchPath(projectPath, file string) string {
+	return filepath.Join(projectPath, PatchDir, file+".patch")
+}
+
+// PendingPatches lists the project-relative files with a patch in PatchDir
+// waiting to be reviewed and applied
+func (b *BatchProcessor) PendingPatches() ([]string, error) {
+	dir := filepath.Join(b.projectPath, PatchDir)
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if file, ok := strings.CutSuffix(path, ".patch"); ok && !entry.IsDir() {
+			rel, err := filepath.Rel(dir, file)
+			if err != nil {
+				return err
+			}
+			files = append(files, rel)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
@@ -0,0 +1,24 @@
+//go:build !unix
+
+package automation
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup is a no-op where process groups are not supported
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// interruptProcessGroup interrupts the step process, killing it where
+// interrupts cannot be sent
+func interruptProcessGroup(process *os.Process) {
+	if err := process.Signal(os.Interrupt); err != nil {
+		process.Kill()
+	}
+}
+
+// killProcessGroup kills the step process
+func killProcessGroup(process *os.Process) {
+	process.Kill()
+}
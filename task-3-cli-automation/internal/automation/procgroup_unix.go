@@ -0,0 +1,25 @@
+//go:build unix
+
+package automation
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts the command in a process group of its own so
+// interrupting a step reaches every process it started
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// interruptProcessGroup sends SIGINT to the process group of a step
+func interruptProcessGroup(process *os.Process) {
+	syscall.Kill(-process.Pid, syscall.SIGINT)
+}
+
+// killProcessGroup sends SIGKILL to the process group of a step
+func killProcessGroup(process *os.Process) {
+	syscall.Kill(-process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,125 @@
+package automation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxBackoff caps the wait between two attempts of a step
+const maxBackoff = 10 * time.Minute
+
+// RetryPolicy re-runs a failed step:
+//
+//	retry:
+//	  attempts: 3          # runs in total, including the first
+//	  backoff: 5s          # wait before the first retry, doubled after each
+//	  on_exit_codes: [75]  # only retry these exit codes; empty retries any failure
+//
+// A step interrupted because the run was cancelled is not retried.
+type RetryPolicy struct {
+	Attempts    int           `yaml:"attempts"`
+	Backoff     time.Duration `yaml:"backoff,omitempty"`
+	OnExitCodes []int         `yaml:"on_exit_codes,omitempty"`
+}
+
+// StepAttempt records one run of a step
+type StepAttempt struct {
+	Attempt   int
+	ExitCode  int // -1 when the step did not exit normally
+	Error     error
+	StartTime time.Time
+	EndTime   time.Time
+	Duration  time.Duration
+}
+
+// attempts returns how many times a step may run in total
+func (p *RetryPolicy) attempts() int {
+	if p == nil || p.Attempts < 1 {
+		return 1
+	}
+	return p.Attempts
+}
+
+// retries reports whether a failure with the exit code may be retried
+func (p *RetryPolicy) retries(exitCode int) bool {
+	if len(p.OnExitCodes) == 0 {
+		return true
+	}
+	for _, code := range p.OnExitCodes {
+		if code == exitCode {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the wait after the given failed attempt
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// runStep runs a step, retrying it according to its retry policy. The
+// result is that of the last attempt, with the output of every attempt.
+func (a *AutomationService) runStep(ctx context.Context, step WorkflowStep, env map[string]string, console *sync.Mutex) StepResult {
+	var (
+		attempts []StepAttempt
+		output   strings.Builder
+		stdout   strings.Builder
+		stderr   strings.Builder
+		result   StepResult
+	)
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			header := fmt.Sprintf("--- attempt %d of %d ---\n", attempt, step.Retry.attempts())
+			output.WriteString(header)
+			stdout.WriteString(header)
+			stderr.WriteString(header)
+		}
+		result = a.runStepWithOutputs(ctx, step, env, console)
+		output.WriteString(result.Output)
+		stdout.WriteString(result.Stdout)
+		stderr.WriteString(result.Stderr)
+		attempts = append(attempts, StepAttempt{
+			Attempt:   attempt,
+			ExitCode:  result.ExitCode,
+			Error:     result.Error,
+			StartTime: result.StartTime,
+			EndTime:   result.EndTime,
+			Duration:  result.Duration,
+		})
+
+		if result.Success || attempt >= step.Retry.attempts() || ctx.Err() != nil || !step.Retry.retries(result.ExitCode) {
+			break
+		}
+
+		delay := step.Retry.backoff(attempt)
+		console.Lock()
+		fmt.Printf("    ↻ %s failed (%v), retrying in %v (attempt %d of %d)\n",
+			step.Name, result.Error, delay, attempt+1, step.Retry.attempts())
+		console.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+		timer.Stop()
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	result.Output = output.String()
+	result.Stdout, result.Stderr = stdout.String(), stderr.String()
+	result.Attempts = attempts
+	result.StartTime = attempts[0].StartTime
+	result.Duration = result.EndTime.Sub(result.StartTime)
+	return result
+}
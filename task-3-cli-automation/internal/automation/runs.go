@@ -0,0 +1,325 @@
+package automation
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Run history lives in .k3ss-ai/runs/<workflow>/<run-id>/, holding run.json
+// with the run's metadata and steps/<step-id>.log with each step's output.
+// Run ids start with the start time so they are easy to find by date.
+
+// runIDTimeFormat is the layout of the start time that begins a run id
+const runIDTimeFormat = "20060102-150405"
+
+// Retention limits how much run history is kept for each workflow
+type Retention struct {
+	KeepRuns int           // newest runs to keep; 0 keeps all
+	MaxAge   time.Duration // runs older than this are pruned; 0 keeps runs of any age
+}
+
+// RunRecord is the stored metadata of one workflow run
+type RunRecord struct {
+	ID        string        `json:"id"`
+	Workflow  string        `json:"workflow"`
+	Trigger   string        `json:"trigger"`
+	Status    string        `json:"status"` // success, failure or skipped
+	Error     string        `json:"error,omitempty"`
+	StartTime time.Time     `json:"start_time"`
+	EndTime   time.Time     `json:"end_time"`
+	Duration  time.Duration `json:"duration"`
+	Steps     []StepRecord  `json:"steps,omitempty"`
+}
+
+// StepRecord is the stored metadata of one step of a run
+type StepRecord struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Phase     string            `json:"phase,omitempty"` // on_failure or always for handlers
+	Status    string            `json:"status"`          // success, failure or skipped
+	Error     string            `json:"error,omitempty"`
+	StartTime time.Time         `json:"start_time,omitempty"`
+	EndTime   time.Time         `json:"end_time,omitempty"`
+	Duration  time.Duration     `json:"duration"`
+	Outputs   map[string]string `json:"outputs,omitempty"`
+	Attempts  []AttemptRecord   `json:"attempts,omitempty"`
+	Sandbox   *SandboxReport    `json:"sandbox,omitempty"`    // limits applied to the last attempt
+	Log       string            `json:"log,omitempty"`        // path of the output log within the run directory
+	StdoutLog string            `json:"stdout_log,omitempty"` // path of the log of stdout alone
+	StderrLog string            `json:"stderr_log,omitempty"` // path of the log of stderr alone
+}
+
+// AttemptRecord is the stored metadata of one attempt of a step
+type AttemptRecord struct {
+	Attempt   int           `json:"attempt"`
+	ExitCode  int           `json:"exit_code"`
+	Error     string        `json:"error,omitempty"`
+	StartTime time.Time     `json:"start_time"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// SetRetention sets how much run history is kept; runs beyond it are pruned
+// after every run
+func (a *AutomationService) SetRetention(retention Retention) {
+	a.retention = retention
+}
+
+// newRunID returns a unique, sortable id for a run starting at start
+func newRunID(start time.Time) string {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return start.Format(runIDTimeFormat) + "-" + hex.EncodeToString(suffix)
+}
+
+// runsDir returns the directory holding the runs of a workflow
+func (a *AutomationService) runsDir(workflow string) string {
+	return filepath.Join(a.projectPath, ".k3ss-ai", "runs", workflow)
+}
+
+// recordRun stores a finished run and prunes old runs of the workflow
+func (a *AutomationService) recordRun(result *WorkflowResult, trigger string) error {
+	dir := filepath.Join(a.runsDir(result.WorkflowName), result.RunID)
+	if err := os.MkdirAll(filepath.Join(dir, "steps"), 0755); err != nil {
+		return fmt.Errorf("failed to create run directory: %w", err)
+	}
+
+	record := &RunRecord{
+		ID:        result.RunID,
+		Workflow:  result.WorkflowName,
+		Trigger:   trigger,
+		Status:    runStatus(result.Success, result.Skipped),
+		StartTime: result.StartTime,
+		EndTime:   result.EndTime,
+		Duration:  result.Duration,
+	}
+	if result.Error != nil {
+		record.Error = result.Error.Error()
+	}
+
+	for _, step := range result.Steps {
+		stepRecord := StepRecord{
+			ID:        step.StepID,
+			Name:      step.StepName,
+			Phase:     step.Phase,
+			Status:    runStatus(step.Success, step.Skipped),
+			StartTime: step.StartTime,
+			EndTime:   step.EndTime,
+			Duration:  step.Duration,
+			Outputs:   step.Outputs,
+			Sandbox:   step.Sandbox,
+		}
+		if step.Error != nil {
+			stepRecord.Error = step.Error.Error()
+		}
+		for _, attempt := range step.Attempts {
+			attemptRecord := AttemptRecord{
+				Attempt:   attempt.Attempt,
+				ExitCode:  attempt.ExitCode,
+				StartTime: attempt.StartTime,
+				Duration:  attempt.Duration,
+			}
+			if attempt.Error != nil {
+				attemptRecord.Error = attempt.Error.Error()
+			}
+			stepRecord.Attempts = append(stepRecord.Attempts, attemptRecord)
+		}
+		if !step.Skipped {
+			logs := []struct {
+				path   *string
+				stream string
+				output string
+			}{
+				{&stepRecord.Log, "", step.Output},
+				{&stepRecord.StdoutLog, "stdout", step.Stdout},
+				{&stepRecord.StderrLog, "stderr", step.Stderr},
+			}
+			for _, log := range logs {
+				*log.path = filepath.ToSlash(filepath.Join("steps", logFileName(step.StepID, log.stream)))
+				if err := os.WriteFile(filepath.Join(dir, *log.path), []byte(log.output), 0644); err != nil {
+					return fmt.Errorf("failed to write step log: %w", err)
+				}
+			}
+		}
+		record.Steps = append(record.Steps, stepRecord)
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run: %w", err)
+	}
+	temp := filepath.Join(dir, "run.json.tmp")
+	if err := os.WriteFile(temp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write run: %w", err)
+	}
+	if err := os.Rename(temp, filepath.Join(dir, "run.json")); err != nil {
+		return fmt.Errorf("failed to write run: %w", err)
+	}
+
+	return a.pruneRuns(result.WorkflowName, result.EndTime)
+}
+
+// runStatus names the outcome of a run or step
+func runStatus(success, skipped bool) string {
+	switch {
+	case skipped:
+		return "skipped"
+	case success:
+		return "success"
+	default:
+		return "failure"
+	}
+}
+
+// logFileName turns a step id into the file name of the log of a stream:
+// <id>.log for the combined output, <id>.stdout.log and <id>.stderr.log
+func logFileName(stepID, stream string) string {
+	name := strings.NewReplacer("/", "_", `\`, "_").Replace(stepID)
+	if stream != "" {
+		name += "." + stream
+	}
+	return name + ".log"
+}
+
+// RunHistory returns the recorded runs of a workflow, newest first. Runs
+// whose metadata cannot be read are left out.
+func (a *AutomationService) RunHistory(workflow string) ([]*RunRecord, error) {
+	records, err := a.runRecords(workflow)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	return records, nil
+}
+
+// FindRun returns the run with the given id, looking through every workflow
+func (a *AutomationService) FindRun(id string) (*RunRecord, error) {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("run '%s' not found", id)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(a.projectPath, ".k3ss-ai", "runs"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read run history: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(a.runsDir(entry.Name()), id, "run.json")); err == nil {
+			return a.readRun(entry.Name(), id)
+		}
+	}
+	return nil, fmt.Errorf("run '%s' not found", id)
+}
+
+// StepLog returns the output a step of a run wrote: stdout and stderr
+// interleaved for stream "", or only the "stdout" or "stderr" stream
+func (a *AutomationService) StepLog(record *RunRecord, step StepRecord, stream string) (string, error) {
+	var log string
+	switch stream {
+	case "":
+		log = step.Log
+	case "stdout":
+		log = step.StdoutLog
+	case "stderr":
+		log = step.StderrLog
+	default:
+		return "", fmt.Errorf("unknown stream %q (expected stdout or stderr)", stream)
+	}
+	if log == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(filepath.Join(a.runsDir(record.Workflow), record.ID, filepath.FromSlash(log)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read log of step %s: %w", step.ID, err)
+	}
+	return string(data), nil
+}
+
+// lastRun returns the start time of the newest recorded run of a workflow
+func (a *AutomationService) lastRun(workflow string) time.Time {
+	records, err := a.runRecords(workflow)
+	if err != nil || len(records) == 0 {
+		return time.Time{}
+	}
+	return records[len(records)-1].StartTime
+}
+
+// runRecords reads the readable runs of a workflow, oldest first
+func (a *AutomationService) runRecords(workflow string) ([]*RunRecord, error) {
+	entries, err := os.ReadDir(a.runsDir(workflow))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read run history: %w", err)
+	}
+
+	var records []*RunRecord
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if record, err := a.readRun(workflow, entry.Name()); err == nil {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].StartTime.Equal(records[j].StartTime) {
+			return records[i].StartTime.Before(records[j].StartTime)
+		}
+		return records[i].ID < records[j].ID
+	})
+	return records, nil
+}
+
+func (a *AutomationService) readRun(workflow, id string) (*RunRecord, error) {
+	path := filepath.Join(a.runsDir(workflow), id, "run.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run: %w", err)
+	}
+
+	var record RunRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	record.ID, record.Workflow = id, workflow // the directory is authoritative
+	return &record, nil
+}
+
+// pruneRuns removes the runs of a workflow beyond the retention limits
+func (a *AutomationService) pruneRuns(workflow string, now time.Time) error {
+	if a.retention.KeepRuns <= 0 && a.retention.MaxAge <= 0 {
+		return nil
+	}
+
+	records, err := a.runRecords(workflow)
+	if err != nil {
+		return err
+	}
+
+	for i, record := range records {
+		expired := a.retention.KeepRuns > 0 && len(records)-i > a.retention.KeepRuns
+		if a.retention.MaxAge > 0 && now.Sub(record.StartTime) > a.retention.MaxAge {
+			expired = true
+		}
+		if expired {
+			if err := os.RemoveAll(filepath.Join(a.runsDir(workflow), record.ID)); err != nil {
+				return fmt.Errorf("failed to prune run %s: %w", record.ID, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package automation
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultEnvAllowlist lists the host environment variables a sandboxed step
+// sees when no allowlist is configured. A trailing * matches any suffix.
+// Variables set by the workflow or step are always passed.
+var DefaultEnvAllowlist = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "LANG", "LC_*", "TZ", "TMPDIR", "GIT_*",
+}
+
+// Sandbox confines the processes of workflow and batch steps. The host
+// environment is scrubbed down to an allowlist everywhere; resource limits
+// and read-only paths are applied on Linux, the latter only where user
+// namespaces are available. Whatever cannot be applied is left out and
+// reported rather than failing the step.
+type Sandbox struct {
+	CPUTime       time.Duration // CPU time of each process, rounded up to whole seconds; 0 for no limit
+	Memory        uint64        // address space of each process in bytes; 0 for no limit
+	OpenFiles     uint64        // open file descriptors of each process; 0 for no limit
+	EnvAllowlist  []string      // host variables passed to steps; nil uses DefaultEnvAllowlist
+	ReadOnlyPaths []string      // mounted read-only while the step runs, relative to the project
+}
+
+// SandboxReport records which sandbox limits were applied to a step
+type SandboxReport struct {
+	CPUTime       time.Duration `json:"cpu_time,omitempty"`
+	Memory        uint64        `json:"memory,omitempty"`
+	OpenFiles     uint64        `json:"open_files,omitempty"`
+	ReadOnlyPaths []string      `json:"read_only_paths,omitempty"`
+	EnvWithheld   int           `json:"env_withheld"`        // host variables left out by the allowlist
+	Fallbacks     []string      `json:"fallbacks,omitempty"` // limits that could not be applied, and why
+}
+
+// String lists the applied limits, e.g. "cpu 10m0s, memory 2048 MiB, 1024 open files"
+func (r *SandboxReport) String() string {
+	var parts []string
+	if r.CPUTime > 0 {
+		parts = append(parts, fmt.Sprintf("cpu %v", r.CPUTime))
+	}
+	if r.Memory > 0 {
+		parts = append(parts, fmt.Sprintf("memory %d MiB", r.Memory>>20))
+	}
+	if r.OpenFiles > 0 {
+		parts = append(parts, fmt.Sprintf("%d open files", r.OpenFiles))
+	}
+	if len(r.ReadOnlyPaths) > 0 {
+		parts = append(parts, "read-only "+strings.Join(r.ReadOnlyPaths, ", "))
+	}
+	parts = append(parts, fmt.Sprintf("env allowlist (%d withheld)", r.EnvWithheld))
+	return strings.Join(parts, ", ")
+}
+
+// SetSandbox runs every step in sandbox; nil turns the sandbox off
+func (a *AutomationService) SetSandbox(sandbox *Sandbox) {
+	a.sandbox = sandbox
+}
+
+// environ returns the host environment a step starts from: all of it
+// without a sandbox, otherwise the allowlisted variables along with the
+// number left out
+func (s *Sandbox) environ() ([]string, int) {
+	if s == nil {
+		return os.Environ(), 0
+	}
+
+	allowlist := s.EnvAllowlist
+	if allowlist == nil {
+		allowlist = DefaultEnvAllowlist
+	}
+
+	var env []string
+	withheld := 0
+	for _, entry := range os.Environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		if envAllowed(allowlist, name) {
+			env = append(env, entry)
+		} else {
+			withheld++
+		}
+	}
+	return env, withheld
+}
+
+// envAllowed reports whether a variable name matches an allowlist entry
+func envAllowed(allowlist []string, name string) bool {
+	for _, pattern := range allowlist {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// readOnlyPaths resolves the read-only paths against the project
+func (s *Sandbox) readOnlyPaths(projectPath string) []string {
+	var paths []string
+	for _, path := range s.ReadOnlyPaths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(projectPath, path)
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
@@ -0,0 +1,322 @@
+//go:build linux
+
+package automation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// A sandboxed step is started through this executable, re-run under the
+// name sandboxHelper with the sandbox spec and the step's command line as
+// arguments. The helper applies the read-only mounts and resource limits to
+// itself, writes a sandboxStatus to the status pipe and execs the command,
+// so the limits apply to the step and everything it starts.
+const sandboxHelper = "k3ss-ai-sandbox"
+
+// Capabilities and prctl options the helper needs to mount inside its user
+// namespace and to make sure the step cannot undo the mounts
+const (
+	capSysAdmin          = 21
+	prSetSecurebits      = 28
+	prCapAmbient         = 47
+	prCapAmbientClearAll = 4
+	secbitNoroot         = 1 << 0
+	secbitNorootLocked   = 1 << 1
+	capabilityVersion3   = 0x20080522
+)
+
+// capHeader and capData are the arguments of the capget and capset syscalls
+type capHeader struct {
+	version uint32
+	pid     int32
+}
+
+type capData struct {
+	effective   uint32
+	permitted   uint32
+	inheritable uint32
+}
+
+// sandboxSpec is what the helper applies before running the step
+type sandboxSpec struct {
+	StatusFD      int      `json:"status_fd"`
+	CPUTime       uint64   `json:"cpu_time,omitempty"` // seconds
+	Memory        uint64   `json:"memory,omitempty"`
+	OpenFiles     uint64   `json:"open_files,omitempty"`
+	ReadOnlyPaths []string `json:"read_only_paths,omitempty"`
+}
+
+// sandboxStatus is what the helper reports back before running the step
+type sandboxStatus struct {
+	Report SandboxReport `json:"report"`
+	Error  string        `json:"error,omitempty"` // the step could not be started
+}
+
+func init() {
+	if len(os.Args) > 1 && os.Args[0] == sandboxHelper {
+		runSandboxHelper(os.Args[1:])
+	}
+}
+
+var (
+	userNamespacesOnce sync.Once
+	userNamespacesErr  error
+)
+
+// userNamespaces reports whether steps can be started in a user and mount
+// namespace of their own, trying it once
+func userNamespaces() error {
+	userNamespacesOnce.Do(func() {
+		self, err := os.Executable()
+		if err != nil {
+			userNamespacesErr = err
+			return
+		}
+		probe := &exec.Cmd{Path: self, Args: []string{sandboxHelper, "probe"}}
+		probe.SysProcAttr = &syscall.SysProcAttr{}
+		setNamespaces(probe.SysProcAttr)
+		if err := probe.Run(); err != nil {
+			userNamespacesErr = fmt.Errorf("user namespaces unavailable: %w", err)
+		}
+	})
+	return userNamespacesErr
+}
+
+// setNamespaces starts a process in new user and mount namespaces, as the
+// same user and holding the capability to mount until it drops it
+func setNamespaces(attr *syscall.SysProcAttr) {
+	attr.Cloneflags |= syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS
+	attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+	attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+	attr.GidMappingsEnableSetgroups = false
+	attr.AmbientCaps = []uintptr{capSysAdmin}
+}
+
+// start starts cmd in the sandbox, or as it is when s is nil, and returns
+// the limits that were applied to it. An error from the sandbox itself is
+// reported as a fallback; an error starting the command is returned once
+// the process has exited.
+func (s *Sandbox) start(cmd *exec.Cmd, projectPath string) (*SandboxReport, error) {
+	if s == nil {
+		return nil, cmd.Start()
+	}
+
+	report := &SandboxReport{}
+	self, err := os.Executable()
+	if err != nil {
+		report.Fallbacks = append(report.Fallbacks, fmt.Sprintf("resource limits and read-only paths not applied: %v", err))
+		return report, cmd.Start()
+	}
+
+	spec := sandboxSpec{
+		StatusFD:  3 + len(cmd.ExtraFiles),
+		CPUTime:   uint64((s.CPUTime + time.Second - 1) / time.Second),
+		Memory:    s.Memory,
+		OpenFiles: s.OpenFiles,
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	if len(s.ReadOnlyPaths) > 0 {
+		if err := userNamespaces(); err != nil {
+			report.Fallbacks = append(report.Fallbacks, fmt.Sprintf("read-only paths not applied: %v", err))
+		} else {
+			spec.ReadOnlyPaths = s.readOnlyPaths(projectPath)
+			setNamespaces(cmd.SysProcAttr)
+		}
+	}
+	encoded, err := json.Marshal(spec)
+	if err != nil {
+		return report, err
+	}
+
+	statusRead, statusWrite, err := os.Pipe()
+	if err != nil {
+		return report, fmt.Errorf("failed to create sandbox status pipe: %w", err)
+	}
+	defer statusRead.Close()
+
+	// The helper looks the command up itself, in the step's environment
+	cmd.Args = append([]string{sandboxHelper, string(encoded)}, cmd.Args...)
+	cmd.Path = self
+	cmd.Err = nil
+	cmd.ExtraFiles = append(cmd.ExtraFiles, statusWrite)
+	err = cmd.Start()
+	statusWrite.Close()
+	if err != nil {
+		return report, err
+	}
+
+	// The pipe closes when the helper execs the command or exits; the last
+	// status it wrote wins
+	var status *sandboxStatus
+	decoder := json.NewDecoder(statusRead)
+	for {
+		var next sandboxStatus
+		if err := decoder.Decode(&next); err != nil {
+			break
+		}
+		status = &next
+	}
+	if status == nil {
+		cmd.Wait()
+		return report, errors.New("sandbox failed to start the command")
+	}
+
+	status.Report.Fallbacks = append(report.Fallbacks, status.Report.Fallbacks...)
+	if status.Error != "" {
+		cmd.Wait()
+		return &status.Report, errors.New(status.Error)
+	}
+	return &status.Report, nil
+}
+
+// runSandboxHelper applies a sandbox spec to the current process and execs
+// the command that follows it. It never returns.
+func runSandboxHelper(args []string) {
+	if args[0] == "probe" {
+		os.Exit(0)
+	}
+
+	var spec sandboxSpec
+	if len(args) < 2 || json.Unmarshal([]byte(args[0]), &spec) != nil {
+		fmt.Fprintln(os.Stderr, "k3ss-ai: invalid sandbox arguments")
+		os.Exit(127)
+	}
+	syscall.CloseOnExec(spec.StatusFD)
+	statusFile := os.NewFile(uintptr(spec.StatusFD), "sandbox-status")
+
+	var status sandboxStatus
+	writeStatus := func() {
+		data, _ := json.Marshal(status)
+		statusFile.Write(append(data, '\n'))
+	}
+	fail := func(err error) {
+		status.Error = err.Error()
+		writeStatus()
+		os.Exit(127)
+	}
+
+	path, err := exec.LookPath(args[1])
+	if err != nil {
+		fail(err)
+	}
+
+	report := &status.Report
+	if len(spec.ReadOnlyPaths) > 0 {
+		applyReadOnlyPaths(spec.ReadOnlyPaths, report)
+	}
+
+	if spec.CPUTime > 0 {
+		// The soft limit sends SIGXCPU, the hard limit a second later SIGKILL
+		if err := setLimit(syscall.RLIMIT_CPU, spec.CPUTime, spec.CPUTime+1); err != nil {
+			report.Fallbacks = append(report.Fallbacks, fmt.Sprintf("cpu time limit not applied: %v", err))
+		} else {
+			report.CPUTime = time.Duration(spec.CPUTime) * time.Second
+		}
+	}
+	if spec.OpenFiles > 0 {
+		if err := setLimit(syscall.RLIMIT_NOFILE, spec.OpenFiles, spec.OpenFiles); err != nil {
+			report.Fallbacks = append(report.Fallbacks, fmt.Sprintf("open file limit not applied: %v", err))
+		} else {
+			report.OpenFiles = spec.OpenFiles
+		}
+	}
+
+	// Limit memory last: the status is encoded beforehand, as if it will
+	// apply, so nothing is allocated under the limit
+	if spec.Memory > 0 {
+		report.Memory = spec.Memory
+		data, _ := json.Marshal(status)
+		if err := setLimit(syscall.RLIMIT_AS, spec.Memory, spec.Memory); err != nil {
+			report.Memory = 0
+			report.Fallbacks = append(report.Fallbacks, fmt.Sprintf("memory limit not applied: %v", err))
+			writeStatus()
+		} else {
+			statusFile.Write(append(data, '\n'))
+		}
+	} else {
+		writeStatus()
+	}
+
+	err = syscall.Exec(path, args[1:], os.Environ())
+	fail(fmt.Errorf("failed to start %s: %w", args[1], err))
+}
+
+// setLimit sets a resource limit of the current process
+func setLimit(resource int, soft, hard uint64) error {
+	return syscall.Setrlimit(resource, &syscall.Rlimit{Cur: soft, Max: hard})
+}
+
+// applyReadOnlyPaths bind-mounts each path read-only over itself, then drops
+// the capability to mount so the step cannot undo it. The helper runs in a
+// mount namespace of its own, so the mounts vanish with the step.
+func applyReadOnlyPaths(paths []string, report *SandboxReport) {
+	// Keep the mounts from propagating back to the host
+	syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, "")
+
+	for _, path := range paths {
+		if err := mountReadOnly(path); err != nil {
+			report.Fallbacks = append(report.Fallbacks, fmt.Sprintf("%s not made read-only: %v", path, err))
+		} else {
+			report.ReadOnlyPaths = append(report.ReadOnlyPaths, path)
+		}
+	}
+
+	// The working directory may now be hidden under a mount
+	if dir, err := os.Getwd(); err == nil {
+		os.Chdir(dir)
+	}
+
+	// Stop exec from granting capabilities to root, then drop the ambient
+	// and inheritable capabilities so the command runs without any
+	syscall.RawSyscall(syscall.SYS_PRCTL, prSetSecurebits, secbitNoroot|secbitNorootLocked, 0)
+	syscall.RawSyscall(syscall.SYS_PRCTL, prCapAmbient, prCapAmbientClearAll, 0)
+	header := capHeader{version: capabilityVersion3}
+	var data [2]capData
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_CAPGET, uintptr(unsafe.Pointer(&header)), uintptr(unsafe.Pointer(&data[0])), 0); errno == 0 {
+		data[0].inheritable, data[1].inheritable = 0, 0
+		syscall.RawSyscall(syscall.SYS_CAPSET, uintptr(unsafe.Pointer(&header)), uintptr(unsafe.Pointer(&data[0])), 0)
+	}
+}
+
+// mountReadOnly bind-mounts path read-only over itself, keeping the flags
+// of the mount it lies on, which cannot be cleared inside a user namespace
+func mountReadOnly(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	if err := syscall.Mount(path, path, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+		return err
+	}
+
+	flags := uintptr(syscall.MS_BIND | syscall.MS_REMOUNT | syscall.MS_RDONLY)
+	var stat syscall.Statfs_t
+	if syscall.Statfs(path, &stat) == nil {
+		for statFlag, mountFlag := range map[int64]uintptr{
+			0x0002: syscall.MS_NOSUID,
+			0x0004: syscall.MS_NODEV,
+			0x0008: syscall.MS_NOEXEC,
+			0x0400: syscall.MS_NOATIME,
+			0x0800: syscall.MS_NODIRATIME,
+			0x1000: syscall.MS_RELATIME,
+		} {
+			if int64(stat.Flags)&statFlag != 0 {
+				flags |= mountFlag
+			}
+		}
+	}
+	if err := syscall.Mount("", path, "", flags, ""); err != nil {
+		syscall.Unmount(path, syscall.MNT_DETACH)
+		return err
+	}
+	return nil
+}
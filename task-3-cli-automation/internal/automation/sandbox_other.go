@@ -0,0 +1,24 @@
+//go:build !linux
+
+package automation
+
+import (
+	"os/exec"
+)
+
+// start starts cmd with the parts of the sandbox that apply outside Linux,
+// reporting the limits left out, or as it is when s is nil
+func (s *Sandbox) start(cmd *exec.Cmd, projectPath string) (*SandboxReport, error) {
+	if s == nil {
+		return nil, cmd.Start()
+	}
+
+	report := &SandboxReport{}
+	if s.CPUTime > 0 || s.Memory > 0 || s.OpenFiles > 0 {
+		report.Fallbacks = append(report.Fallbacks, "resource limits not applied: only supported on Linux")
+	}
+	if len(s.ReadOnlyPaths) > 0 {
+		report.Fallbacks = append(report.Fallbacks, "read-only paths not applied: only supported on Linux")
+	}
+	return report, cmd.Start()
+}
@@ -0,0 +1,217 @@
+package automation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// maxMissedRuns caps how many missed fire times are counted per workflow
+const maxMissedRuns = 1000
+
+// ScheduledWorkflow is a schedule workflow with its parsed cron expression
+// and run history
+type ScheduledWorkflow struct {
+	Workflow *Workflow
+	Schedule *Schedule
+	LastFire time.Time // fire time of the last scheduled run; zero if it never ran
+	Missed   int       // fire times between LastFire and now that did not run
+	Next     time.Time // next fire time after now
+}
+
+// SchedulerOptions configures RunScheduler
+type SchedulerOptions struct {
+	SkipMissed bool // record missed runs without running them
+
+	// OnResult is called after each scheduled run
+	OnResult func(name string, fire time.Time, result *WorkflowResult, err error)
+}
+
+// scheduleHistory records the last scheduled run of each workflow so missed
+// runs can be detected after a restart
+type scheduleHistory struct {
+	Workflows map[string]*scheduleEntry `json:"workflows"`
+}
+
+type scheduleEntry struct {
+	LastFire time.Time `json:"last_fire"`          // fire time the run was scheduled for
+	LastRun  time.Time `json:"last_run,omitempty"` // when it actually started
+	Success  bool      `json:"success"`
+}
+
+// ScheduledWorkflows returns every schedule workflow, sorted by name, with
+// its next fire time after now and the runs it missed since its last
+// recorded run
+func (a *AutomationService) ScheduledWorkflows(now time.Time) ([]ScheduledWorkflow, error) {
+	history, err := a.loadScheduleHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	var scheduled []ScheduledWorkflow
+	for _, workflow := range a.workflows {
+		if workflow.Trigger.Type != "schedule" {
+			continue
+		}
+		schedule, err := ParseSchedule(workflow.Trigger.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("workflow '%s' is invalid: %w", workflow.Name, err)
+		}
+
+		entry := ScheduledWorkflow{Workflow: workflow, Schedule: schedule, Next: schedule.Next(now)}
+		if recorded := history.Workflows[workflow.Name]; recorded != nil {
+			entry.LastFire = recorded.LastFire.In(now.Location())
+			for fire := schedule.Next(entry.LastFire); !fire.IsZero() && !fire.After(now) && entry.Missed < maxMissedRuns; fire = schedule.Next(fire) {
+				entry.Missed++
+			}
+		}
+		scheduled = append(scheduled, entry)
+	}
+
+	sort.Slice(scheduled, func(i, j int) bool { return scheduled[i].Workflow.Name < scheduled[j].Workflow.Name })
+	return scheduled, nil
+}
+
+// RunScheduler runs schedule workflows at their fire times until ctx is
+// cancelled. A workflow that missed runs while the scheduler was stopped
+// runs once on start, unless SkipMissed is set. A workflow with no history
+// starts counting from now.
+func (a *AutomationService) RunScheduler(ctx context.Context, opts SchedulerOptions) error {
+	scheduled, err := a.ScheduledWorkflows(time.Now())
+	if err != nil {
+		return err
+	}
+	if len(scheduled) == 0 {
+		return fmt.Errorf("no schedule workflows found")
+	}
+
+	history, err := a.loadScheduleHistory()
+	if err != nil {
+		return err
+	}
+	record := func(name string, fire time.Time, result *WorkflowResult) error {
+		entry := &scheduleEntry{LastFire: fire}
+		if result != nil {
+			entry.LastRun = result.StartTime
+			entry.Success = result.Success
+		}
+		history.Workflows[name] = entry
+		return a.saveScheduleHistory(history)
+	}
+	run := func(name string, fire time.Time) error {
+		result, err := a.RunWorkflow(ctx, name, RunOptions{Trigger: "schedule"})
+		if opts.OnResult != nil {
+			opts.OnResult(name, fire, result, err)
+		}
+		return record(name, fire, result)
+	}
+
+	// next holds the upcoming fire time of each workflow
+	now := time.Now()
+	next := make(map[string]time.Time, len(scheduled))
+	schedules := make(map[string]*Schedule, len(scheduled))
+	for _, entry := range scheduled {
+		name := entry.Workflow.Name
+		schedules[name] = entry.Schedule
+		next[name] = entry.Next
+
+		switch {
+		case entry.LastFire.IsZero():
+			if err := record(name, now, nil); err != nil {
+				return err
+			}
+		case entry.Missed > 0:
+			fmt.Printf("⏰ Workflow %s missed %d scheduled run(s) since %s\n",
+				name, entry.Missed, entry.LastFire.Format("2006-01-02 15:04"))
+			fire := now.Truncate(time.Minute)
+			if opts.SkipMissed {
+				err = record(name, fire, nil)
+			} else {
+				err = run(name, fire)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		var due time.Time
+		for _, fire := range next {
+			if !fire.IsZero() && (due.IsZero() || fire.Before(due)) {
+				due = fire
+			}
+		}
+		if due.IsZero() {
+			return fmt.Errorf("no schedule fires within the next five years")
+		}
+
+		timer := time.NewTimer(time.Until(due))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		names := make([]string, 0, len(next))
+		for name, fire := range next {
+			if !fire.IsZero() && !fire.After(time.Now()) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := run(name, next[name]); err != nil {
+				return err
+			}
+			next[name] = schedules[name].Next(time.Now())
+		}
+	}
+}
+
+// scheduleHistoryPath is where the scheduler records its runs
+func (a *AutomationService) scheduleHistoryPath() string {
+	return filepath.Join(a.projectPath, ".k3ss-ai", "scheduler.json")
+}
+
+func (a *AutomationService) loadScheduleHistory() (*scheduleHistory, error) {
+	history := &scheduleHistory{Workflows: make(map[string]*scheduleEntry)}
+	data, err := os.ReadFile(a.scheduleHistoryPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return history, nil
+		}
+		return nil, fmt.Errorf("failed to read scheduler history: %w", err)
+	}
+	if err := json.Unmarshal(data, history); err != nil {
+		return nil, fmt.Errorf("failed to parse scheduler history %s: %w", a.scheduleHistoryPath(), err)
+	}
+	if history.Workflows == nil {
+		history.Workflows = make(map[string]*scheduleEntry)
+	}
+	return history, nil
+}
+
+// saveScheduleHistory writes the history through a temporary file so a crash
+// never leaves it half written
+func (a *AutomationService) saveScheduleHistory(history *scheduleHistory) error {
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode scheduler history: %w", err)
+	}
+
+	path := a.scheduleHistoryPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write scheduler history: %w", err)
+	}
+	return os.Rename(temp, path)
+}
@@ -1,11 +1,17 @@
 package automation
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -13,6 +19,12 @@ import (
 type AutomationService struct {
 	projectPath string
 	workflows   map[string]*Workflow
+	loadErrors  map[string]error
+	parallelism int
+	retention   Retention
+	stdout      io.Writer // where running steps stream their output; nil turns streaming off
+	stderr      io.Writer
+	sandbox     *Sandbox // confines step processes; nil runs them unconfined
 }
 
 // NewAutomationService creates a new automation service instance
@@ -23,42 +35,64 @@ func NewAutomationService(projectPath string) *AutomationService {
 	return &AutomationService{
 		projectPath: projectPath,
 		workflows:   make(map[string]*Workflow),
+		loadErrors:  make(map[string]error),
+		stdout:      os.Stdout,
+		stderr:      os.Stderr,
 	}
 }
 
 // Workflow represents an automation workflow
 type Workflow struct {
-	Name        string
-	Description string
-	Trigger     WorkflowTrigger
-	Steps       []WorkflowStep
-	Environment map[string]string
-	Created     time.Time
-	LastRun     time.Time
+	Name        string                   `yaml:"name"`
+	Description string                   `yaml:"description,omitempty"`
+	Trigger     WorkflowTrigger          `yaml:"trigger"`
+	Steps       []WorkflowStep           `yaml:"steps"`
+	Environment map[string]string        `yaml:"environment,omitempty"`
+	Parallelism int                      `yaml:"parallelism,omitempty"` // concurrent steps; 0 means one per CPU
+	Timeout     time.Duration            `yaml:"timeout,omitempty"`     // the whole run is interrupted when it runs longer
+	OnFailure   []WorkflowStep           `yaml:"on_failure,omitempty"`  // run in order after a step fails
+	Always      []WorkflowStep           `yaml:"always,omitempty"`      // run in order after every run, even a cancelled one
+	Inputs      map[string]TemplateInput `yaml:"inputs,omitempty"`      // parameters when used as a template
+	Created     time.Time                `yaml:"created,omitempty"`
+	LastRun     time.Time                `yaml:"-"`
+	Source      string                   `yaml:"-"` // file the workflow was loaded from
 }
 
 // WorkflowTrigger defines when a workflow should run
 type WorkflowTrigger struct {
-	Type       string // "manual", "file_change", "git_hook", "schedule"
-	Pattern    string // file pattern for file_change, cron for schedule
-	Events     []string
-	Conditions []string
+	Type       string   `yaml:"type"`              // "manual", "file_change", "git_hook", "schedule"
+	Pattern    string   `yaml:"pattern,omitempty"` // file pattern for file_change, cron for schedule
+	Events     []string `yaml:"events,omitempty"`
+	Conditions []string `yaml:"conditions,omitempty"`
 }
 
 // WorkflowStep represents a single step in a workflow
 type WorkflowStep struct {
-	Name        string
-	Command     string
-	Args        []string
-	WorkingDir  string
-	Environment map[string]string
-	ContinueOnError bool
+	ID              string            `yaml:"id,omitempty"`
+	Name            string            `yaml:"name"`
+	Command         string            `yaml:"command,omitempty"`
+	Args            []string          `yaml:"args,omitempty"`
+	WorkingDir      string            `yaml:"working_dir,omitempty"`
+	Environment     map[string]string `yaml:"environment,omitempty"`
+	ContinueOnError bool              `yaml:"continue_on_error,omitempty"`
+	Needs           []string          `yaml:"needs,omitempty"`   // ids of steps that must succeed first
+	If              string            `yaml:"if,omitempty"`      // expression; the step is skipped when false
+	Timeout         time.Duration     `yaml:"timeout,omitempty"` // e.g. 10m; the step is interrupted when it runs longer
+	Retry           *RetryPolicy      `yaml:"retry,omitempty"`
+	Uses            string            `yaml:"uses,omitempty"` // template to run instead of a command: ./file.yaml or builtin/NAME
+	With            map[string]string `yaml:"with,omitempty"` // inputs passed to the template
+	
+	scope     *stepScope  // set on steps taken from a template
+	guards    []stepGuard // if: conditions of the uses: steps the step was taken from
+	composite bool        // stands for a uses: step, finishing when the template's steps do
 }
 
 // WorkflowResult represents the result of workflow execution
 type WorkflowResult struct {
+	RunID        string // directory of the run under .k3ss-ai/runs/<workflow>
 	WorkflowName string
 	Success      bool
+	Skipped      bool // trigger conditions were not met
 	Duration     time.Duration
 	Steps        []StepResult
 	Error        error
@@ -68,11 +102,36 @@ type WorkflowResult struct {
 
 // StepResult represents the result of a single step
 type StepResult struct {
-	StepName string
-	Success  bool
-	Output   string
-	Error    error
-	Duration time.Duration
+	StepID    string
+	StepName  string
+	Phase     string // "" for workflow steps, "on_failure" or "always" for handlers
+	Success   bool
+	Skipped   bool              // not run because its if: was false or a step it needs failed
+	Output    string            // stdout and stderr as they were interleaved, across all attempts
+	Stdout    string            // across all attempts
+	Stderr    string            // across all attempts
+	Outputs   map[string]string // values written to $K3SS_OUTPUT
+	ExitCode  int               // of the last attempt; -1 when it did not exit normally
+	Attempts  []StepAttempt
+	Sandbox   *SandboxReport // limits applied to the last attempt; nil without a sandbox
+	Error     error
+	StartTime time.Time
+	EndTime   time.Time
+	Duration  time.Duration
+}
+
+// SetStepOutput sets where running steps stream their output, line by line
+// and prefixed with the step name. Passing nil writers turns streaming off;
+// the output is still captured in each StepResult.
+func (a *AutomationService) SetStepOutput(stdout, stderr io.Writer) {
+	a.stdout = stdout
+	a.stderr = stderr
+}
+
+// SetParallelism limits how many independent steps run at once, overriding
+// the workflow's own setting. Zero restores the workflow's setting.
+func (a *AutomationService) SetParallelism(n int) {
+	a.parallelism = n
 }
 
 // CreateWorkflow creates a new workflow
@@ -94,33 +153,188 @@ func (a *AutomationService) CreateWorkflow(name, description string, trigger Wor
 	return a.saveWorkflow(workflow)
 }
 
+// RunOptions describes what started a workflow run
+type RunOptions struct {
+	Trigger string            // what started the run, recorded in its history; default "manual"
+	Env     map[string]string // extra environment, e.g. the files that changed
+}
+
 // ExecuteWorkflow executes a workflow by name
 func (a *AutomationService) ExecuteWorkflow(name string) (*WorkflowResult, error) {
-	workflow, exists := a.workflows[name]
-	if !exists {
-		return nil, fmt.Errorf("workflow '%s' not found", name)
+	return a.RunWorkflow(context.Background(), name, RunOptions{})
+}
+
+// RunWorkflow executes a workflow by name with the environment of the event
+// that triggered it. Event values are visible to expressions as env.NAME and
+// are passed to every step. Cancelling ctx interrupts the running steps and
+// skips the rest.
+func (a *AutomationService) RunWorkflow(ctx context.Context, name string, opts RunOptions) (*WorkflowResult, error) {
+	workflow, err := a.GetWorkflow(name)
+	if err != nil {
+		return nil, err
+	}
+	
+	exprCtx, err := a.newExprContext(workflow, opts.Env)
+	if err != nil {
+		return nil, fmt.Errorf("workflow '%s' is invalid: %w", name, err)
+	}
+	
+	// Steps with uses: are replaced by the steps of their templates
+	expanded, err := a.expandWorkflow(workflow, exprCtx.scope)
+	if err != nil {
+		return nil, fmt.Errorf("workflow '%s' is invalid: %w", name, err)
+	}
+	steps := expanded.Steps
+	
+	graph, err := buildStepGraph(steps)
+	if err != nil {
+		return nil, fmt.Errorf("workflow '%s' is invalid: %w", name, err)
+	}
+	
+	runCtx := ctx
+	if workflow.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, workflow.Timeout)
+		defer cancel()
 	}
 	
 	result := &WorkflowResult{
 		WorkflowName: name,
 		StartTime:    time.Now(),
-		Steps:        make([]StepResult, 0, len(workflow.Steps)),
+		Steps:        make([]StepResult, len(steps)),
+	}
+	result.RunID = newRunID(result.StartTime)
+	if opts.Trigger == "" {
+		opts.Trigger = "manual"
+	}
+	
+	if met, err := conditionsMet(workflow, exprCtx); err != nil || !met {
+		if err != nil {
+			return nil, fmt.Errorf("workflow '%s' is invalid: %w", name, err)
+		}
+		fmt.Printf("⏭️  Skipping workflow %s: trigger conditions not met\n", name)
+		result.Steps = nil
+		result.Success = true
+		result.Skipped = true
+		result.EndTime = time.Now()
+		a.saveRun(result, opts.Trigger)
+		return result, nil
+	}
+	env := make(map[string]string, len(opts.Env)+len(workflow.Environment))
+	for key := range opts.Env {
+		env[key] = exprCtx.env[key]
+	}
+	for key := range workflow.Environment {
+		env[key] = exprCtx.env[key]
 	}
 	
 	fmt.Printf("🚀 Executing workflow: %s\n", name)
 	
-	for i, step := range workflow.Steps {
-		fmt.Printf("  Step %d/%d: %s\n", i+1, len(workflow.Steps), step.Name)
-		
-		stepResult := a.executeStep(step)
-		result.Steps = append(result.Steps, stepResult)
-		
-		if !stepResult.Success && !step.ContinueOnError {
+	// Each step waits for the steps it needs, then for a free slot. A step
+	// whose dependency failed is skipped, which in turn skips its dependents.
+	done := make([]chan struct{}, len(steps))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	slots := make(chan struct{}, a.parallelismFor(workflow))
+	var mu sync.Mutex // guards exprCtx.steps and console output
+	
+	for i := range steps {
+		go func(i int) {
+			defer close(done[i])
+			step := steps[i]
+			skip := func(err error) {
+				result.Steps[i] = StepResult{StepID: graph.ids[i], StepName: step.Name, Skipped: true, Success: err == nil, Error: err}
+				mu.Lock()
+				exprCtx.steps[graph.ids[i]] = &stepContext{result: "skipped"}
+				mu.Unlock()
+			}
+			
+			if step.composite {
+				parts := make([]StepResult, len(graph.needs[i]))
+				partSteps := make([]WorkflowStep, len(graph.needs[i]))
+				for j, dependency := range graph.needs[i] {
+					<-done[dependency]
+					parts[j], partSteps[j] = result.Steps[dependency], steps[dependency]
+				}
+				stepResult := compositeResult(step, parts, partSteps)
+				stepResult.StepID = graph.ids[i]
+				result.Steps[i] = stepResult
+				mu.Lock()
+				exprCtx.steps[graph.ids[i]] = &stepContext{result: stepOutcome(stepResult), outputs: stepResult.Outputs}
+				mu.Unlock()
+				return
+			}
+			
+			for _, dependency := range graph.needs[i] {
+				<-done[dependency]
+				if blocked := result.Steps[dependency]; !blocked.Success && (blocked.Skipped || !steps[dependency].ContinueOnError) {
+					skip(fmt.Errorf("skipped because step %q did not succeed", graph.ids[dependency]))
+					return
+				}
+			}
+			
+			mu.Lock()
+			rendered, run, err := renderStep(step, exprCtx)
+			mu.Unlock()
+			if err != nil {
+				result.Steps[i] = StepResult{StepID: graph.ids[i], StepName: step.Name, Error: err}
+				mu.Lock()
+				exprCtx.steps[graph.ids[i]] = &stepContext{result: "failure"}
+				mu.Unlock()
+				return
+			}
+			if !run {
+				skip(nil)
+				return
+			}
+			
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-runCtx.Done():
+			}
+			if runCtx.Err() != nil {
+				skip(errors.New("cancelled before it started"))
+				return
+			}
+			
+			mu.Lock()
+			fmt.Printf("  Step %d/%d: %s\n", i+1, len(steps), step.Name)
+			mu.Unlock()
+			
+			stepResult := a.runStep(runCtx, rendered, env, &mu)
+			stepResult.StepID = graph.ids[i]
+			result.Steps[i] = stepResult
+			
+			mu.Lock()
+			exprCtx.steps[graph.ids[i]] = &stepContext{result: stepOutcome(stepResult), outputs: stepResult.Outputs}
+			mu.Unlock()
+		}(i)
+	}
+	for i := range done {
+		<-done[i]
+	}
+	
+	for i, stepResult := range result.Steps {
+		if !stepResult.Success && (stepResult.Skipped || !steps[i].ContinueOnError) {
 			result.Success = false
-			result.Error = stepResult.Error
-			break
+			if result.Error == nil {
+				result.Error = fmt.Errorf("step %q failed: %w", stepResult.StepID, stepResult.Error)
+			}
 		}
 	}
+	if runCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		result.Error = fmt.Errorf("workflow timed out after %v", workflow.Timeout)
+	}
+	
+	// Handlers run even when the run was cancelled, so cleanup still happens;
+	// their own timeouts bound them
+	handlerCtx := context.WithoutCancel(ctx)
+	if result.Error != nil {
+		a.runHandlers(handlerCtx, "on_failure", workflow.OnFailure, exprCtx, env, result)
+	}
+	a.runHandlers(handlerCtx, "always", workflow.Always, exprCtx, env, result)
 	
 	result.EndTime = time.Now()
 	result.Duration = result.EndTime.Sub(result.StartTime)
@@ -130,39 +344,201 @@ func (a *AutomationService) ExecuteWorkflow(name string) (*WorkflowResult, error
 	}
 	
 	workflow.LastRun = result.StartTime
+	a.saveRun(result, opts.Trigger)
 	
 	return result, nil
 }
 
-// executeStep executes a single workflow step
-func (a *AutomationService) executeStep(step WorkflowStep) StepResult {
+// saveRun records a run in the history. Failing to record it does not fail
+// the run, so the problem is only reported.
+func (a *AutomationService) saveRun(result *WorkflowResult, trigger string) {
+	if err := a.recordRun(result, trigger); err != nil {
+		fmt.Printf("Warning: failed to record run %s: %v\n", result.RunID, err)
+	}
+}
+
+// runHandlers runs the on_failure or always steps of a workflow in order,
+// after the workflow's steps, adding their results to the run. A failing
+// handler fails the run unless it continues on error.
+func (a *AutomationService) runHandlers(ctx context.Context, phase string, steps []WorkflowStep, exprCtx *exprContext, env map[string]string, result *WorkflowResult) {
+	var mu sync.Mutex
+	for i, step := range steps {
+		stepResult := StepResult{StepID: HandlerStepID(phase, i, step), StepName: step.Name, Skipped: true, Success: true}
+		rendered, run, err := renderStep(step, exprCtx)
+		switch {
+		case err != nil:
+			stepResult = StepResult{StepID: stepResult.StepID, StepName: step.Name, Error: err}
+		case run:
+			fmt.Printf("  %s %d/%d: %s\n", phase, i+1, len(steps), step.Name)
+			stepResult = a.runStep(ctx, rendered, env, &mu)
+			stepResult.StepID = HandlerStepID(phase, i, step)
+		}
+		stepResult.Phase = phase
+		result.Steps = append(result.Steps, stepResult)
+		exprCtx.steps[stepResult.StepID] = &stepContext{result: stepOutcome(stepResult), outputs: stepResult.Outputs}
+		
+		if !stepResult.Success && !step.ContinueOnError && result.Error == nil {
+			result.Error = fmt.Errorf("%s step %q failed: %w", phase, stepResult.StepID, stepResult.Error)
+		}
+	}
+}
+
+// stepOutcome is the steps.<id>.result of a finished step
+func stepOutcome(result StepResult) string {
+	switch {
+	case result.Skipped:
+		return "skipped"
+	case !result.Success:
+		return "failure"
+	}
+	return "success"
+}
+
+// runStepWithOutputs executes a step with $K3SS_OUTPUT pointing at a fresh
+// file and collects the outputs the step wrote to it
+func (a *AutomationService) runStepWithOutputs(ctx context.Context, step WorkflowStep, env map[string]string, console *sync.Mutex) StepResult {
+	outputFile, err := os.CreateTemp("", "k3ss-output-*")
+	if err != nil {
+		return StepResult{StepName: step.Name, ExitCode: -1, Error: fmt.Errorf("failed to create output file: %w", err)}
+	}
+	outputFile.Close()
+	defer os.Remove(outputFile.Name())
+	
+	stepEnv := make(map[string]string, len(env)+1)
+	for key, value := range env {
+		stepEnv[key] = value
+	}
+	stepEnv[OutputEnv] = outputFile.Name()
+	
+	result := a.executeStep(ctx, step, stepEnv, console)
+	outputs, err := readOutputs(outputFile.Name())
+	if err != nil && result.Success {
+		result.Success = false
+		result.Error = fmt.Errorf("invalid %s: %w", OutputEnv, err)
+	}
+	result.Outputs = outputs
+	return result
+}
+
+// executeStep executes a single workflow step, streaming its output to the
+// console line by line. The step runs in its own process group, which is
+// interrupted when ctx is cancelled or the step's timeout expires and killed
+// if it has not exited after killGrace. console serialises console writes.
+func (a *AutomationService) executeStep(ctx context.Context, step WorkflowStep, env map[string]string, console *sync.Mutex) StepResult {
 	startTime := time.Now()
 	
+	stepCtx := ctx
+	if step.Timeout > 0 {
+		var cancel context.CancelFunc
+		stepCtx, cancel = context.WithTimeout(ctx, step.Timeout)
+		defer cancel()
+	}
+	
 	// Prepare command
 	cmd := exec.Command(step.Command, step.Args...)
+	setProcessGroup(cmd)
 	
-	// Set working directory
-	if step.WorkingDir != "" {
+	// Set working directory, resolving relative paths against the project
+	cmd.Dir = a.projectPath
+	if filepath.IsAbs(step.WorkingDir) {
 		cmd.Dir = step.WorkingDir
-	} else {
-		cmd.Dir = a.projectPath
+	} else if step.WorkingDir != "" {
+		cmd.Dir = filepath.Join(a.projectPath, step.WorkingDir)
 	}
 	
-	// Set environment variables
-	cmd.Env = os.Environ()
+	// Set environment variables, letting step values override workflow values
+	// and keeping only the allowlisted host variables in a sandbox
+	hostEnv, withheld := a.sandbox.environ()
+	cmd.Env = hostEnv
+	for key, value := range env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
+	}
 	for key, value := range step.Environment {
 		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
 	}
 	
+	// Stream output while capturing each stream and both interleaved
+	combined := &lockedBuffer{}
+	prefix := fmt.Sprintf("    [%s] ", step.Name)
+	stdout := newLineWriter(a.stdout, console, prefix, combined)
+	stderr := newLineWriter(a.stderr, console, prefix, combined)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	
+	// Don't wait forever for background processes that keep the output open
+	cmd.WaitDelay = killGrace
+	
 	// Execute command
-	output, err := cmd.CombinedOutput()
+	sandbox, err := a.sandbox.start(cmd, a.projectPath)
+	if sandbox != nil {
+		sandbox.EnvWithheld = withheld
+	}
+	if err == nil {
+		done := make(chan struct{})
+		go func() {
+			select {
+			case <-done:
+				return
+			case <-stepCtx.Done():
+			}
+			interruptProcessGroup(cmd.Process)
+			select {
+			case <-done:
+			case <-time.After(killGrace):
+				killProcessGroup(cmd.Process)
+			}
+		}()
+		
+		err = cmd.Wait()
+		close(done)
+		if stepCtx.Err() != nil {
+			// Reap background processes that outlived the interrupted step
+			killProcessGroup(cmd.Process)
+		}
+		if errors.Is(err, exec.ErrWaitDelay) {
+			err = nil
+		}
+	}
+	stdout.Flush()
+	stderr.Flush()
+	
+	switch {
+	case ctx.Err() != nil:
+		err = errors.New("cancelled")
+	case stepCtx.Err() != nil:
+		err = fmt.Errorf("timed out after %v", step.Timeout)
+	}
+	
+	exitCode := -1
+	if cmd.ProcessState != nil {
+		exitCode = cmd.ProcessState.ExitCode()
+	}
 	
+	endTime := time.Now()
 	return StepResult{
-		StepName: step.Name,
-		Success:  err == nil,
-		Output:   string(output),
-		Error:    err,
-		Duration: time.Since(startTime),
+		StepName:  step.Name,
+		Success:   err == nil,
+		Output:    combined.String(),
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		ExitCode:  exitCode,
+		Sandbox:   sandbox,
+		Error:     err,
+		StartTime: startTime,
+		EndTime:   endTime,
+		Duration:  endTime.Sub(startTime),
+	}
+}
+
+// parallelismFor returns how many steps of workflow may run at once
+func (a *AutomationService) parallelismFor(workflow *Workflow) int {
+	switch {
+	case a.parallelism > 0:
+		return a.parallelism
+	case workflow.Parallelism > 0:
+		return workflow.Parallelism
+	default:
+		return runtime.NumCPU()
 	}
 }
 
@@ -177,6 +553,10 @@ func (a *AutomationService) ListWorkflows() []*Workflow {
 
 // GetWorkflow returns a workflow by name
 func (a *AutomationService) GetWorkflow(name string) (*Workflow, error) {
+	if err, failed := a.loadErrors[name]; failed {
+		return nil, fmt.Errorf("workflow '%s' is invalid: %w", name, err)
+	}
+	
 	workflow, exists := a.workflows[name]
 	if !exists {
 		return nil, fmt.Errorf("workflow '%s' not found", name)
@@ -204,41 +584,14 @@ func (a *AutomationService) saveWorkflow(workflow *Workflow) error {
 		return fmt.Errorf("failed to create workflow directory: %w", err)
 	}
 	
-	// TODO: Implement YAML serialization
-	workflowPath := filepath.Join(workflowDir, workflow.Name+".yaml")
-	content := a.serializeWorkflow(workflow)
-	
-	return os.WriteFile(workflowPath, []byte(content), 0644)
-}
-
-// serializeWorkflow converts workflow to YAML format
-func (a *AutomationService) serializeWorkflow(workflow *Workflow) string {
-	var content strings.Builder
-	
-	content.WriteString(fmt.Sprintf("name: %s\n", workflow.Name))
-	content.WriteString(fmt.Sprintf("description: %s\n", workflow.Description))
-	content.WriteString("trigger:\n")
-	content.WriteString(fmt.Sprintf("  type: %s\n", workflow.Trigger.Type))
-	if workflow.Trigger.Pattern != "" {
-		content.WriteString(fmt.Sprintf("  pattern: %s\n", workflow.Trigger.Pattern))
-	}
-	
-	content.WriteString("steps:\n")
-	for _, step := range workflow.Steps {
-		content.WriteString(fmt.Sprintf("  - name: %s\n", step.Name))
-		content.WriteString(fmt.Sprintf("    command: %s\n", step.Command))
-		if len(step.Args) > 0 {
-			content.WriteString("    args:\n")
-			for _, arg := range step.Args {
-				content.WriteString(fmt.Sprintf("      - %s\n", arg))
-			}
-		}
-		if step.ContinueOnError {
-			content.WriteString("    continue_on_error: true\n")
-		}
+	content, err := MarshalWorkflow(workflow)
+	if err != nil {
+		return err
 	}
 	
-	return content.String()
+	workflowPath := filepath.Join(workflowDir, workflow.Name+".yaml")
+	workflow.Source = workflowPath
+	return os.WriteFile(workflowPath, content, 0644)
 }
 
 // LoadWorkflows loads all workflows from disk
@@ -268,84 +621,72 @@ func (a *AutomationService) LoadWorkflows() error {
 	return nil
 }
 
-// loadWorkflowFromFile loads a single workflow from file
+// loadWorkflowFromFile loads a single workflow from file. A file that fails
+// to parse is remembered so running it reports the problem instead of "not found".
 func (a *AutomationService) loadWorkflowFromFile(path string) error {
-	// TODO: Implement YAML deserialization
-	// For now, create a placeholder workflow
 	name := strings.TrimSuffix(filepath.Base(path), ".yaml")
 	
-	workflow := &Workflow{
-		Name:        name,
-		Description: "Loaded from file",
-		Trigger:     WorkflowTrigger{Type: "manual"},
-		Steps:       []WorkflowStep{},
-		Environment: make(map[string]string),
-		Created:     time.Now(),
+	workflow, err := LoadWorkflowFile(path)
+	if err == nil {
+		// Report missing templates and bad inputs now rather than at run time
+		_, err = a.expandWorkflow(workflow, nil)
+	}
+	if err != nil {
+		a.loadErrors[name] = err
+		return err
 	}
 	
-	a.workflows[name] = workflow
+	delete(a.loadErrors, workflow.Name)
+	workflow.LastRun = a.lastRun(workflow.Name)
+	a.workflows[workflow.Name] = workflow
 	return nil
 }
 
-// CreatePrebuiltWorkflows creates common automation workflows
-func (a *AutomationService) CreatePrebuiltWorkflows() error {
-	// Deploy staging workflow
-	deployStaging := []WorkflowStep{
-		{
-			Name:    "Install dependencies",
-			Command: "npm",
-			Args:    []string{"install"},
-		},
-		{
-			Name:    "Run tests",
-			Command: "npm",
-			Args:    []string{"test"},
-		},
-		{
-			Name:    "Build application",
-			Command: "npm",
-			Args:    []string{"run", "build"},
-		},
-		{
-			Name:    "Deploy to staging",
-			Command: "npm",
-			Args:    []string{"run", "deploy:staging"},
-		},
-	}
-	
-	if err := a.CreateWorkflow(
-		"deploy-staging",
-		"Deploy application to staging environment",
-		WorkflowTrigger{Type: "git_hook", Events: []string{"push:main"}},
-		deployStaging,
-	); err != nil {
-		return err
+// CreatePrebuiltWorkflows creates common automation workflows from the
+// built-in library in the flavour of a build system such as go, cargo, maven
+// or npm. An empty buildSystem uses the flavour detected in the project.
+func (a *AutomationService) CreatePrebuiltWorkflows(buildSystem string) ([]*Workflow, error) {
+	if buildSystem == "" {
+		buildSystem = DetectFlavour(a.projectPath)
 	}
 	
-	// Code quality check workflow
-	qualityCheck := []WorkflowStep{
-		{
-			Name:    "Lint code",
-			Command: "npm",
-			Args:    []string{"run", "lint"},
-		},
-		{
-			Name:    "Type check",
-			Command: "npm",
-			Args:    []string{"run", "type-check"},
-		},
-		{
-			Name:    "Security audit",
-			Command: "npm",
-			Args:    []string{"audit"},
-		},
-	}
-	
-	return a.CreateWorkflow(
-		"quality-check",
-		"Run code quality and security checks",
-		WorkflowTrigger{Type: "git_hook", Events: []string{"pre-commit"}},
-		qualityCheck,
-	)
+	files, err := fs.ReadDir(library, "library/workflows/"+buildSystem)
+	if err != nil {
+		return nil, fmt.Errorf("no prebuilt workflows for build system %q (available: %s)", buildSystem, strings.Join(PrebuiltFlavours(), ", "))
+	}
+	
+	workflowDir := filepath.Join(a.projectPath, ".k3ss-ai", "workflows")
+	if err := os.MkdirAll(workflowDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create workflow directory: %w", err)
+	}
+	
+	var created []*Workflow
+	for _, file := range files {
+		data, err := library.ReadFile("library/workflows/" + buildSystem + "/" + file.Name())
+		if err != nil {
+			return nil, err
+		}
+		
+		// Files are copied as written so their comments survive
+		workflowPath := filepath.Join(workflowDir, file.Name())
+		if err := os.WriteFile(workflowPath, data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write workflow: %w", err)
+		}
+		if err := a.loadWorkflowFromFile(workflowPath); err != nil {
+			return nil, err
+		}
+		created = append(created, a.workflows[strings.TrimSuffix(file.Name(), ".yaml")])
+	}
+	return created, nil
+}
+
+// PrebuiltFlavours lists the build systems CreatePrebuiltWorkflows supports
+func PrebuiltFlavours() []string {
+	entries, _ := fs.ReadDir(library, "library/workflows")
+	flavours := make([]string, len(entries))
+	for i, entry := range entries {
+		flavours[i] = entry.Name()
+	}
+	return flavours
 }
 
@@ -0,0 +1,85 @@
+package automation
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+// killGrace is how long an interrupted step may take to exit before its
+// process group is killed
+const killGrace = 5 * time.Second
+
+// lockedBuffer is a bytes.Buffer safe for concurrent writes, used to capture
+// stdout and stderr interleaved
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// lineWriter captures one output stream of a step and echoes it to the
+// console a complete line at a time, each line prefixed with the step name
+type lineWriter struct {
+	console  io.Writer   // nil when streaming is off
+	mu       *sync.Mutex // serialises console writes across steps
+	prefix   string
+	captured bytes.Buffer
+	combined *lockedBuffer
+	partial  []byte // the unfinished last line
+}
+
+func newLineWriter(console io.Writer, mu *sync.Mutex, prefix string, combined *lockedBuffer) *lineWriter {
+	return &lineWriter{console: console, mu: mu, prefix: prefix, combined: combined}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.captured.Write(p)
+	w.combined.Write(p)
+	if w.console == nil {
+		return len(p), nil
+	}
+
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.writeLine(w.partial[:i+1])
+		w.partial = w.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes out an unfinished last line
+func (w *lineWriter) Flush() {
+	if w.console != nil && len(w.partial) > 0 {
+		w.writeLine(append(w.partial, '\n'))
+		w.partial = nil
+	}
+}
+
+func (w *lineWriter) writeLine(line []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	io.WriteString(w.console, w.prefix)
+	w.console.Write(line)
+}
+
+// String returns everything written so far
+func (w *lineWriter) String() string {
+	return w.captured.String()
+}
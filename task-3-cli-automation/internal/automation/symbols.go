@@ -0,0 +1,300 @@
+package automation
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// codeSymbol is a public declaration found in a source file
+type codeSymbol struct {
+	Name       string // Recv.Method for Go methods
+	Kind       string // func, method, type, const, var or class
+	Signature  string // the first line of the declaration, trimmed
+	Line       int    // 1-based line the declaration starts on
+	BodyLine   int    // Python: 1-based line ending the header, after which a docstring goes; 0 when the body shares the header's line
+	Indent     string // leading whitespace of the declaration's line
+	Documented bool
+}
+
+// sourceLanguage names the language of a file from its extension, or ""
+func sourceLanguage(file string) string {
+	switch filepath.Ext(file) {
+	case ".go":
+		return "go"
+	case ".js", ".jsx", ".mjs", ".cjs":
+		return "javascript"
+	case ".ts", ".tsx":
+		return "typescript"
+	case ".py":
+		return "python"
+	default:
+		return ""
+	}
+}
+
+// findSymbols lists the public declarations of a source file in order:
+// exported Go declarations, exported JavaScript and TypeScript functions,
+// classes and constants, and top-level Python functions and classes not
+// starting with an underscore
+func findSymbols(file string, src []byte) ([]codeSymbol, error) {
+	switch sourceLanguage(file) {
+	case "go":
+		return goSymbols(file, src)
+	case "javascript", "typescript":
+		return scriptSymbols(src), nil
+	case "python":
+		return pythonSymbols(src), nil
+	default:
+		return nil, fmt.Errorf("unsupported file type: %s", filepath.Ext(file))
+	}
+}
+
+// goSymbols lists the exported declarations of a Go file
+func goSymbols(file string, src []byte) ([]codeSymbol, error) {
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, file, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(string(src), "\n")
+	symbol := func(name, kind string, pos token.Pos, documented bool) codeSymbol {
+		line := fset.Position(pos).Line
+		text := lines[line-1]
+		return codeSymbol{
+			Name:       name,
+			Kind:       kind,
+			Signature:  strings.TrimSpace(text),
+			Line:       line,
+			Indent:     text[:len(text)-len(strings.TrimLeft(text, " \t"))],
+			Documented: documented,
+		}
+	}
+
+	var symbols []codeSymbol
+	for _, decl := range parsed.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if !decl.Name.IsExported() {
+				continue
+			}
+			name, kind := decl.Name.Name, "func"
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				recv := receiverName(decl.Recv.List[0].Type)
+				if !ast.IsExported(recv) {
+					continue
+				}
+				name, kind = recv+"."+name, "method"
+			}
+			symbols = append(symbols, symbol(name, kind, decl.Pos(), decl.Doc != nil))
+
+		case *ast.GenDecl:
+			if decl.Tok == token.IMPORT {
+				continue
+			}
+			grouped := decl.Lparen.IsValid()
+			for _, spec := range decl.Specs {
+				var name string
+				var documented bool
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					name, documented = spec.Name.Name, spec.Doc != nil || spec.Comment != nil
+				case *ast.ValueSpec:
+					for _, ident := range spec.Names {
+						if ident.IsExported() {
+							name = ident.Name
+							break
+						}
+					}
+					documented = spec.Doc != nil || spec.Comment != nil
+				}
+				if name == "" || !ast.IsExported(name) {
+					continue
+				}
+
+				// A single declaration is documented above its keyword, a
+				// grouped one above its own line unless the group is
+				if grouped {
+					symbols = append(symbols, symbol(name, decl.Tok.String(), spec.Pos(), documented || decl.Doc != nil))
+				} else {
+					symbols = append(symbols, symbol(name, decl.Tok.String(), decl.Pos(), decl.Doc != nil))
+				}
+			}
+		}
+	}
+	return symbols, nil
+}
+
+// receiverName returns the type name of a method receiver
+func receiverName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(expr.X)
+	case *ast.IndexExpr:
+		return receiverName(expr.X)
+	case *ast.IndexListExpr:
+		return receiverName(expr.X)
+	case *ast.Ident:
+		return expr.Name
+	default:
+		return ""
+	}
+}
+
+// scriptDecl matches the exported declarations of JavaScript and TypeScript
+var scriptDecl = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{"func", regexp.MustCompile(`^export\s+(?:default\s+)?(?:async\s+)?function\s*\*?\s*([A-Za-z_$][\w$]*)`)},
+	{"class", regexp.MustCompile(`^export\s+(?:default\s+)?(?:abstract\s+)?class\s+([A-Za-z_$][\w$]*)`)},
+	{"type", regexp.MustCompile(`^export\s+(?:declare\s+)?(?:interface|type|enum)\s+([A-Za-z_$][\w$]*)`)},
+	{"const", regexp.MustCompile(`^export\s+(?:const|let|var)\s+([A-Za-z_$][\w$]*)`)},
+	{"func", regexp.MustCompile(`^(?:module\.)?exports\.([A-Za-z_$][\w$]*)\s*=`)},
+}
+
+// scriptFunction matches a constant holding a function
+var scriptFunction = regexp.MustCompile(`=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*(?::[^=]*)?=>|[\w$]+\s*=>)`)
+
+// scriptSymbols lists the exported top-level declarations of a JavaScript
+// or TypeScript file, line by line
+func scriptSymbols(src []byte) []codeSymbol {
+	var symbols []codeSymbol
+	lines := strings.Split(string(src), "\n")
+	for i, line := range lines {
+		if line != strings.TrimLeft(line, " \t") {
+			continue // only top-level declarations
+		}
+		for _, decl := range scriptDecl {
+			match := decl.pattern.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			kind := decl.kind
+			if kind == "const" && scriptFunction.MatchString(line) {
+				kind = "func"
+			}
+			previous := ""
+			if i > 0 {
+				previous = strings.TrimSpace(lines[i-1])
+			}
+			symbols = append(symbols, codeSymbol{
+				Name:       match[1],
+				Kind:       kind,
+				Signature:  strings.TrimSpace(line),
+				Line:       i + 1,
+				Documented: strings.HasSuffix(previous, "*/") || strings.HasPrefix(previous, "//"),
+			})
+			break
+		}
+	}
+	return symbols
+}
+
+// pythonDecl matches a top-level Python function or class
+var pythonDecl = regexp.MustCompile(`^(?:async\s+)?(def|class)\s+([A-Za-z_]\w*)`)
+
+// pythonSymbols lists the public top-level functions and classes of a
+// Python file, line by line
+func pythonSymbols(src []byte) []codeSymbol {
+	var symbols []codeSymbol
+	lines := strings.Split(string(src), "\n")
+	for i, line := range lines {
+		match := pythonDecl.FindStringSubmatch(line)
+		if match == nil || strings.HasPrefix(match[2], "_") {
+			continue
+		}
+
+		// The header continues while brackets are open or a line ends in a
+		// backslash. Unless its last line ends in a colon the body follows
+		// on that line, as in def f(): return 1, and leaves no room for a
+		// docstring.
+		bodyLine, depth := 0, 0
+		for end := i; end < len(lines); end++ {
+			code := strings.TrimRightFunc(stripPythonComment(lines[end]), unicode.IsSpace)
+			depth += pythonBrackets(code)
+			if depth > 0 || strings.HasSuffix(code, `\`) {
+				continue
+			}
+			if strings.HasSuffix(code, ":") {
+				bodyLine = end + 1
+			}
+			break
+		}
+
+		// Documented by a docstring opening the body, or by comments above
+		// a declaration whose body shares its line
+		documented := false
+		if bodyLine == 0 {
+			documented = i > 0 && strings.HasPrefix(strings.TrimSpace(lines[i-1]), "#")
+		} else {
+			for _, next := range lines[bodyLine:] {
+				if strings.TrimSpace(next) == "" {
+					continue
+				}
+				next = strings.TrimSpace(next)
+				documented = strings.HasPrefix(next, `"""`) || strings.HasPrefix(next, `'''`) ||
+					strings.HasPrefix(next, `r"""`) || strings.HasPrefix(next, `r'''`)
+				break
+			}
+		}
+
+		kind := "func"
+		if match[1] == "class" {
+			kind = "class"
+		}
+		symbols = append(symbols, codeSymbol{
+			Name:       match[2],
+			Kind:       kind,
+			Signature:  strings.TrimSpace(line),
+			Line:       i + 1,
+			BodyLine:   bodyLine,
+			Documented: documented,
+		})
+	}
+	return symbols
+}
+
+// pythonBrackets returns how many more brackets a line opens than it
+// closes, ignoring those in simple string literals
+func pythonBrackets(line string) int {
+	var quote rune
+	depth := 0
+	for _, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '(' || r == '[' || r == '{':
+			depth++
+		case r == ')' || r == ']' || r == '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+// stripPythonComment drops a trailing # comment from a line, ignoring # in
+// simple string literals
+func stripPythonComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
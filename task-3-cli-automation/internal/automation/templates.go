@@ -0,0 +1,402 @@
+package automation
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// A step with uses: runs the steps of a template in place of a command. A
+// template is a workflow file declaring inputs; only its inputs,
+// environment and steps are used. Templates are either files relative to the
+// workflow using them (./deploy.yaml) or part of the built-in library
+// (builtin/quality), whose variant is picked by DetectFlavour unless named (builtin/quality/go, or builtin/go-quality).
+//
+// Template steps keep ids of their own: within the template they refer to
+// each other as usual, and outside it they appear as <uses-id>/<id>. Other
+// steps see the uses: step as one step, which fails when any template step
+// fails and has the outputs of all of them.
+
+// library holds the built-in templates, templates/<name>/<variant>.yaml, and
+// the workflows "workflow init" creates, workflows/<variant>/<name>.yaml
+//
+//go:embed library
+var library embed.FS
+
+// builtinPrefix starts the uses: value of a built-in template
+const builtinPrefix = "builtin/"
+
+// DefaultFlavour is the flavour of the built-in library used for a project
+// with none of the files marking a build system
+const DefaultFlavour = "npm"
+
+// flavourMarkers names the file marking each flavour of the built-in
+// library, in the order they are looked for
+var flavourMarkers = []struct{ file, flavour string }{
+	{"Cargo.toml", "cargo"},
+	{"go.mod", "go"},
+	{"pom.xml", "maven"},
+	{"package.json", "npm"},
+}
+
+// DetectFlavour returns the flavour of the built-in library matching the
+// project's build system, or DefaultFlavour when none is found. Other build
+// files such as a Makefile are ignored since the library has no flavour for
+// them.
+func DetectFlavour(projectPath string) string {
+	for _, marker := range flavourMarkers {
+		if _, err := os.Stat(filepath.Join(projectPath, marker.file)); err == nil {
+			return marker.flavour
+		}
+	}
+	return DefaultFlavour
+}
+
+// maxTemplateDepth bounds how deeply templates may use other templates
+const maxTemplateDepth = 8
+
+// TemplateInput declares a parameter of a template
+type TemplateInput struct {
+	Description string `yaml:"description,omitempty"`
+	Default     string `yaml:"default,omitempty"`
+	Required    bool   `yaml:"required,omitempty"`
+}
+
+// stepScope is the template a step was taken from: its step ids are
+// prefixed, and inputs.NAME resolves to what the uses: step passed
+type stepScope struct {
+	prefix string
+	inputs map[string]string // rendered in the parent scope
+	parent *stepScope
+}
+
+// stepGuard is the if: condition of a uses: step, evaluated in its scope
+type stepGuard struct {
+	scope     *stepScope
+	condition string
+}
+
+// idPrefix returns what the ids of steps in the scope start with
+func (s *stepScope) idPrefix() string {
+	if s == nil {
+		return ""
+	}
+	return s.prefix
+}
+
+// input renders an input of the current template in the scope of the step
+// that passed it
+func (ctx *exprContext) input(name string) string {
+	if ctx.scope == nil {
+		return ""
+	}
+	value := ctx.scope.inputs[name]
+	t, err := parseTemplate(value)
+	if err != nil {
+		return value
+	}
+	parent := *ctx
+	parent.scope = ctx.scope.parent
+	return t.render(&parent)
+}
+
+// templateInputs merges the values passed to a template with its defaults
+func templateInputs(template *Workflow, with map[string]string) (map[string]string, error) {
+	for name := range with {
+		if _, ok := template.Inputs[name]; !ok {
+			return nil, fmt.Errorf("unknown input %q (expected one of %s)", name, strings.Join(sortedInputs(template), ", "))
+		}
+	}
+
+	inputs := make(map[string]string, len(template.Inputs))
+	for name, input := range template.Inputs {
+		value, ok := with[name]
+		if !ok {
+			if input.Required {
+				return nil, fmt.Errorf("missing required input %q", name)
+			}
+			value = input.Default
+		}
+		inputs[name] = value
+	}
+	return inputs, nil
+}
+
+func sortedInputs(template *Workflow) []string {
+	names := make([]string, 0, len(template.Inputs))
+	for name := range template.Inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validUses checks the form of a uses: value
+func validUses(uses string) error {
+	switch {
+	case strings.HasPrefix(uses, builtinPrefix):
+		parts := strings.Split(strings.TrimPrefix(uses, builtinPrefix), "/")
+		if len(parts) > 2 || parts[0] == "" || (len(parts) == 2 && parts[1] == "") {
+			return fmt.Errorf("invalid template %q (expected builtin/NAME or builtin/NAME/VARIANT)", uses)
+		}
+	case strings.HasPrefix(uses, "./") || strings.HasPrefix(uses, "../"):
+		if ext := path.Ext(uses); ext != ".yaml" && ext != ".yml" {
+			return fmt.Errorf("invalid template %q (expected a .yaml file)", uses)
+		}
+	default:
+		return fmt.Errorf("invalid template %q (expected ./FILE.yaml, ../FILE.yaml or builtin/NAME)", uses)
+	}
+	return nil
+}
+
+// loadTemplate reads the template a uses: value names, resolving files
+// against dir. It returns the template and a key identifying it.
+func (a *AutomationService) loadTemplate(uses, dir string) (*Workflow, string, error) {
+	if err := validUses(uses); err != nil {
+		return nil, "", err
+	}
+
+	if !strings.HasPrefix(uses, builtinPrefix) {
+		file := filepath.Join(dir, filepath.FromSlash(uses))
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read template: %w", err)
+		}
+		template, err := ParseWorkflow(data, file)
+		if err != nil {
+			return nil, "", err
+		}
+		template.Source = file
+		return template, file, nil
+	}
+
+	name, variant, _ := strings.Cut(strings.TrimPrefix(uses, builtinPrefix), "/")
+	if _, err := fs.Stat(library, "library/templates/"+name); err != nil && variant == "" {
+		// builtin/VARIANT-NAME is builtin/NAME/VARIANT
+		if prefix, rest, ok := strings.Cut(name, "-"); ok {
+			if _, err := fs.Stat(library, "library/templates/"+rest); err == nil {
+				name, variant = rest, prefix
+			}
+		}
+	}
+	variants, err := fs.ReadDir(library, "library/templates/"+name)
+	if err != nil {
+		return nil, "", fmt.Errorf("unknown built-in template %q (available: %s)", name, strings.Join(BuiltinTemplates(), ", "))
+	}
+	if variant == "" {
+		variant = DetectFlavour(a.projectPath)
+	}
+
+	file := "library/templates/" + name + "/" + variant + ".yaml"
+	data, err := library.ReadFile(file)
+	if err != nil {
+		available := make([]string, len(variants))
+		for i, entry := range variants {
+			available[i] = strings.TrimSuffix(entry.Name(), ".yaml")
+		}
+		return nil, "", fmt.Errorf("built-in template %q has no %s variant (available: %s)", name, variant, strings.Join(available, ", "))
+	}
+	source := builtinPrefix + name + "/" + variant
+	template, err := ParseWorkflow(data, source)
+	if err != nil {
+		return nil, "", err
+	}
+	template.Source = source
+	return template, source, nil
+}
+
+// BuiltinTemplates lists the built-in templates
+func BuiltinTemplates() []string {
+	entries, _ := fs.ReadDir(library, "library/templates")
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	return names
+}
+
+// expandWorkflow returns the workflow with every uses: step replaced by the
+// steps of its template followed by a composite step standing for it. top
+// is the scope of the workflow itself when it has inputs.
+func (a *AutomationService) expandWorkflow(workflow *Workflow, top *stepScope) (*Workflow, error) {
+	uses := false
+	for _, step := range workflow.Steps {
+		uses = uses || step.Uses != ""
+	}
+	if !uses {
+		return workflow, nil
+	}
+
+	dir := filepath.Dir(workflow.Source)
+	if workflow.Source == "" {
+		dir = filepath.Join(a.projectPath, ".k3ss-ai", "workflows")
+	}
+	steps, err := a.expandSteps(workflow.Steps, workflow.Source, dir, expansion{scope: top})
+	if err != nil {
+		return nil, err
+	}
+
+	expanded := *workflow
+	expanded.Steps = steps
+	return &expanded, nil
+}
+
+// expansion is what steps taken from a template inherit from its uses: step
+type expansion struct {
+	scope           *stepScope
+	needs           []string // what the uses: step needs, for the template's first steps
+	guards          []stepGuard
+	continueOnError bool
+	environment     map[string]string // the template's environment
+	templates       []string          // keys of the templates being expanded, to detect cycles
+}
+
+// expandSteps expands the uses: steps in a list of steps from one file.
+// Every step gets an explicit id and needs, so steps that ran in sequence
+// still do once other steps declare needs.
+func (a *AutomationService) expandSteps(steps []WorkflowStep, source, dir string, from expansion) ([]WorkflowStep, error) {
+	prefix := from.scope.idPrefix()
+	explicit := false
+	ids := make([]string, len(steps))
+	for i, step := range steps {
+		ids[i] = StepID(i, step)
+		explicit = explicit || len(step.Needs) > 0
+	}
+
+	var expanded []WorkflowStep
+	for i, step := range steps {
+		needs := step.Needs
+		if !explicit && i > 0 {
+			needs = []string{ids[i-1]}
+		}
+		fullNeeds := make([]string, len(needs))
+		for j, need := range needs {
+			fullNeeds[j] = prefix + need
+		}
+		if len(fullNeeds) == 0 {
+			fullNeeds = from.needs
+		}
+
+		if step.Uses == "" {
+			step.ID = prefix + ids[i]
+			step.Needs = fullNeeds
+			step.guards = append(from.guards[:len(from.guards):len(from.guards)], step.guards...)
+			step.ContinueOnError = step.ContinueOnError || from.continueOnError
+			if from.scope != nil {
+				step.scope = from.scope
+			}
+			if len(from.environment) > 0 {
+				environment := make(map[string]string, len(from.environment)+len(step.Environment))
+				for name, value := range from.environment {
+					environment[name] = value
+				}
+				for name, value := range step.Environment {
+					environment[name] = value
+				}
+				step.Environment = environment
+			}
+			expanded = append(expanded, step)
+			continue
+		}
+
+		fail := func(err error) error {
+			if _, ok := err.(*WorkflowError); ok {
+				return err
+			}
+			if _, ok := err.(WorkflowErrors); ok {
+				return err
+			}
+			return &WorkflowError{File: source, Line: stepLine(source, i), Message: fmt.Sprintf("step %q: %v", ids[i], err)}
+		}
+		if len(from.templates) >= maxTemplateDepth {
+			return nil, fail(fmt.Errorf("templates are nested more than %d deep", maxTemplateDepth))
+		}
+		template, key, err := a.loadTemplate(step.Uses, dir)
+		if err != nil {
+			return nil, fail(err)
+		}
+		if containsString(from.templates, key) {
+			return nil, fail(fmt.Errorf("template cycle: %s -> %s", strings.Join(from.templates, " -> "), key))
+		}
+		inputs, err := templateInputs(template, step.With)
+		if err != nil {
+			return nil, fail(fmt.Errorf("%s: %w", step.Uses, err))
+		}
+
+		inner := expansion{
+			scope:           &stepScope{prefix: prefix + ids[i] + "/", inputs: inputs, parent: from.scope},
+			needs:           fullNeeds,
+			guards:          from.guards,
+			continueOnError: from.continueOnError || step.ContinueOnError,
+			environment:     template.Environment,
+			templates:       append(append([]string{}, from.templates...), key),
+		}
+		if step.If != "" {
+			inner.guards = append(inner.guards[:len(inner.guards):len(inner.guards)], stepGuard{scope: from.scope, condition: step.If})
+		}
+		templateDir := filepath.Dir(template.Source)
+		templateSteps, err := a.expandSteps(template.Steps, template.Source, templateDir, inner)
+		if err != nil {
+			return nil, err
+		}
+
+		composite := WorkflowStep{
+			ID:              prefix + ids[i],
+			Name:            step.Name,
+			Uses:            step.Uses,
+			ContinueOnError: inner.continueOnError,
+			composite:       true,
+		}
+		for _, templateStep := range templateSteps {
+			// the composite step waits for the template's own steps; those of
+			// templates it uses are covered by their composite steps
+			if !strings.Contains(strings.TrimPrefix(templateStep.ID, inner.scope.prefix), "/") {
+				composite.Needs = append(composite.Needs, templateStep.ID)
+			}
+			templateStep.Name = step.Name + " / " + templateStep.Name
+			expanded = append(expanded, templateStep)
+		}
+		expanded = append(expanded, composite)
+	}
+	return expanded, nil
+}
+
+// compositeResult combines the results of a template's steps into the
+// result of the uses: step that ran them. Like a step that needs them all, it
+// is skipped when one of them did not run because a step it needs failed.
+func compositeResult(step WorkflowStep, parts []StepResult, partSteps []WorkflowStep) StepResult {
+	result := StepResult{StepName: step.Name, Success: true, Skipped: true, Outputs: make(map[string]string)}
+	for i, part := range parts {
+		if !part.Skipped {
+			result.Skipped = false
+			if result.StartTime.IsZero() || part.StartTime.Before(result.StartTime) {
+				result.StartTime = part.StartTime
+			}
+			if part.EndTime.After(result.EndTime) {
+				result.EndTime = part.EndTime
+			}
+		}
+		if !part.Success && (part.Skipped || !partSteps[i].ContinueOnError) && result.Error == nil {
+			result.Success = false
+			result.Error = fmt.Errorf("step %q did not succeed", part.StepID)
+		}
+		for name, value := range part.Outputs {
+			result.Outputs[name] = value
+		}
+	}
+	if !result.Success {
+		// a failure blocked by an earlier one reports like a skipped step
+		blocked := true
+		for _, part := range parts {
+			blocked = blocked && (part.Success || part.Skipped)
+		}
+		result.Skipped = blocked
+	}
+	result.Duration = result.EndTime.Sub(result.StartTime)
+	return result
+}
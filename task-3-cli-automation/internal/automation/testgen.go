@@ -0,0 +1,253 @@
+package automation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/ai"
+)
+
+// DefaultTestFrameworks maps languages to the test framework used when
+// TestOptions names none
+var DefaultTestFrameworks = map[string]string{
+	"go":         "testing",
+	"javascript": "jest",
+	"typescript": "jest",
+	"python":     "pytest",
+}
+
+// TestOptions configures test generation
+type TestOptions struct {
+	Type      string // unit, integration or e2e; unit when empty
+	Framework string // defaults to DefaultTestFrameworks
+}
+
+// testFileFor returns the test file for a source file, next to it
+func testFileFor(file string) (string, error) {
+	ext := filepath.Ext(file)
+	base := strings.TrimSuffix(file, ext)
+	switch sourceLanguage(file) {
+	case "go":
+		return base + "_test.go", nil
+	case "javascript", "typescript":
+		return base + ".test" + ext, nil
+	case "python":
+		return filepath.Join(filepath.Dir(file), "test_"+filepath.Base(base)+".py"), nil
+	default:
+		return "", fmt.Errorf("unsupported file type: %s", ext)
+	}
+}
+
+// isTestFile reports whether file is itself a test
+func isTestFile(file string) bool {
+	name := filepath.Base(file)
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	return strings.HasSuffix(name, "_test.go") ||
+		strings.HasSuffix(base, ".test") || strings.HasSuffix(base, ".spec") ||
+		(filepath.Ext(name) == ".py" && (strings.HasPrefix(base, "test_") || strings.HasSuffix(base, "_test")))
+}
+
+// addTests generates tests for a file, skipping files that are tests
+func (b *BatchProcessor) addTests(ctx context.Context, file string) error {
+	if isTestFile(file) {
+		return nil
+	}
+	b.logf("Adding tests for %s\n", file)
+	testFile, err := b.GenerateTests(ctx, file, TestOptions{})
+	if err != nil {
+		return err
+	}
+	b.logf("Wrote %s\n", testFile)
+	return nil
+}
+
+// GenerateTests asks the AI service for table-driven tests of the public
+// functions of a project-relative file and writes them next to it,
+// returning the test file. Go tests must declare the file's package and
+// pass go vet before they are written.
+func (b *BatchProcessor) GenerateTests(ctx context.Context, file string, options TestOptions) (string, error) {
+	if b.aiClient == nil {
+		return "", fmt.Errorf("test generation needs the AI service")
+	}
+	testFile, err := testFileFor(file)
+	if err != nil {
+		return "", err
+	}
+	testPath := filepath.Join(b.projectPath, testFile)
+	if _, err := os.Stat(testPath); err == nil {
+		return "", fmt.Errorf("test file already exists: %s", testFile)
+	}
+
+	src, err := os.ReadFile(filepath.Join(b.projectPath, file))
+	if err != nil {
+		return "", err
+	}
+	symbols, err := findSymbols(file, src)
+	if err != nil {
+		return "", err
+	}
+	var functions []codeSymbol
+	for _, symbol := range symbols {
+		if symbol.Kind == "func" || symbol.Kind == "method" {
+			functions = append(functions, symbol)
+		}
+	}
+	if len(functions) == 0 {
+		return "", fmt.Errorf("no public functions to test in %s", file)
+	}
+
+	language := sourceLanguage(file)
+	if options.Type == "" {
+		options.Type = "unit"
+	}
+	if options.Framework == "" {
+		options.Framework = DefaultTestFrameworks[language]
+	}
+
+	var packageName string
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Write table-driven %s tests using %s for these public functions of %s:\n\n", options.Type, options.Framework, file)
+	for _, function := range functions {
+		fmt.Fprintf(&prompt, "- %s: %s\n", function.Name, function.Signature)
+	}
+	fmt.Fprintf(&prompt, "\nThe tests go in %s, next to %s. ", filepath.Base(testFile), filepath.Base(file))
+	module := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	switch language {
+	case "go":
+		parsed, err := parser.ParseFile(token.NewFileSet(), file, src, parser.PackageClauseOnly)
+		if err != nil {
+			return "", err
+		}
+		packageName = parsed.Name.Name
+		fmt.Fprintf(&prompt, "It must start with the package clause `package %s` and only use the standard library.", packageName)
+	case "javascript", "typescript":
+		fmt.Fprintf(&prompt, "Import the functions from './%s' using the module syntax %s uses.", module, file)
+	case "python":
+		fmt.Fprintf(&prompt, "Import the functions with `from %s import ...`.", module)
+	}
+	prompt.WriteString(" Reply with only the contents of the test file.")
+
+	response, err := b.aiClient.Ask(ctx, ai.RequestGenerate, prompt.String(), ai.FileInfo{
+		Path:     file,
+		Content:  string(src),
+		Language: language,
+		Size:     len(src),
+	})
+	if err != nil {
+		return "", err
+	}
+	content := codeBlock(response.Content)
+	if strings.TrimSpace(content) == "" {
+		return "", fmt.Errorf("AI service returned no tests for %s", file)
+	}
+
+	if language == "go" {
+		// Tests generated for other files of the package must be in place
+		// when these are vetted, or two files may each pass while declaring
+		// the same helpers
+		unlock := b.lockPackage(filepath.Dir(testFile))
+		defer unlock()
+		if err := b.vetGoTests(ctx, testFile, content, packageName); err != nil {
+			return "", err
+		}
+	}
+	if err := os.WriteFile(testPath, []byte(content), 0644); err != nil {
+		return "", err
+	}
+	return testFile, nil
+}
+
+// lockPackage holds the lock of a package directory until the returned
+// function is called
+func (b *BatchProcessor) lockPackage(dir string) func() {
+	b.packagesMu.Lock()
+	if b.packages == nil {
+		b.packages = make(map[string]*sync.Mutex)
+	}
+	mu, ok := b.packages[dir]
+	if !ok {
+		mu = &sync.Mutex{}
+		b.packages[dir] = mu
+	}
+	b.packagesMu.Unlock()
+
+	mu.Lock()
+	return mu.Unlock
+}
+
+// codeBlock returns the first fenced code block of an AI reply, or the
+// whole reply when it has none, ending in a newline
+func codeBlock(reply string) string {
+	if start := strings.Index(reply, "```"); start >= 0 {
+		rest := reply[start+3:]
+		if newline := strings.Index(rest, "\n"); newline >= 0 {
+			rest = rest[newline+1:]
+			if end := strings.Index(rest, "```"); end >= 0 {
+				rest = rest[:end]
+			}
+			reply = rest
+		}
+	}
+	return strings.TrimSpace(reply) + "\n"
+}
+
+// vetGoTests checks generated Go tests declare the package under test and
+// pass go vet with it, without writing them into the project: go vet sees
+// them through an overlay
+func (b *BatchProcessor) vetGoTests(ctx context.Context, testFile, content, packageName string) error {
+	parsed, err := parser.ParseFile(token.NewFileSet(), testFile, content, parser.PackageClauseOnly)
+	if err != nil {
+		return fmt.Errorf("generated tests do not parse: %w", err)
+	}
+	if name := parsed.Name.Name; name != packageName && name != packageName+"_test" {
+		return fmt.Errorf("generated tests declare package %s, not %s", name, packageName)
+	}
+
+	dir, err := os.MkdirTemp("", "k3ss-ai-tests-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	backing := filepath.Join(dir, filepath.Base(testFile))
+	if err := os.WriteFile(backing, []byte(content), 0644); err != nil {
+		return err
+	}
+	target, err := filepath.Abs(filepath.Join(b.projectPath, testFile))
+	if err != nil {
+		return err
+	}
+	overlay, err := json.Marshal(map[string]map[string]string{"Replace": {target: backing}})
+	if err != nil {
+		return err
+	}
+	overlayFile := filepath.Join(dir, "overlay.json")
+	if err := os.WriteFile(overlayFile, overlay, 0644); err != nil {
+		return err
+	}
+
+	pkg := "./" + filepath.ToSlash(filepath.Dir(testFile))
+	err = b.runCommand(ctx, "go", []string{"vet", "-overlay", overlayFile, pkg})
+	var commandErr *CommandError
+	if errors.As(err, &commandErr) {
+		// Point go vet's messages at the test file rather than its backing copy
+		output := commandErr.Output
+		if projectDir, err := filepath.Abs(b.projectPath); err == nil {
+			if rel, err := filepath.Rel(projectDir, backing); err == nil {
+				output = strings.ReplaceAll(output, rel, testFile)
+			}
+		}
+		output = strings.ReplaceAll(output, backing, testFile)
+		commandErr.Command, commandErr.Output = "go vet "+pkg, output
+		return fmt.Errorf("generated tests fail go vet: %w", commandErr)
+	}
+	return err
+}
@@ -0,0 +1,304 @@
+package automation
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChangedFilesEnv names the variable that holds the files which triggered a
+// file_change workflow, one path per line, relative to the project
+const ChangedFilesEnv = "K3SS_CHANGED_FILES"
+
+// WatchOptions configures Watch
+type WatchOptions struct {
+	Workflows []string      // workflows to run; empty means every file_change workflow
+	Poll      bool          // scan the tree periodically instead of using inotify
+	Interval  time.Duration // how often to scan when polling
+	Debounce  time.Duration // quiet period to wait for before running workflows
+
+	// OnResult is called after each triggered run
+	OnResult func(name string, result *WorkflowResult, err error)
+}
+
+// fileWatcher reports changed paths, relative to the project root. The
+// events channel is closed if the watcher fails.
+type fileWatcher interface {
+	Events() <-chan string
+	Errors() <-chan error
+	Close() error
+}
+
+// Watch watches the project for file changes until ctx is cancelled. Changes
+// are collected until no more arrive for the debounce period, then every
+// workflow whose trigger pattern matches one of the changed files runs once
+// with the matching paths in $K3SS_CHANGED_FILES. Paths ignored by .gitignore
+// or .k3ss-aiignore are not watched.
+func (a *AutomationService) Watch(ctx context.Context, opts WatchOptions) error {
+	workflows, err := a.watchedWorkflows(opts.Workflows)
+	if err != nil {
+		return err
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = 500 * time.Millisecond
+	}
+
+	ignore := newProjectIgnoreMatcher(a.projectPath)
+	var watcher fileWatcher
+	if !opts.Poll {
+		watcher, err = newNativeWatcher(a.projectPath, ignore)
+		if err != nil {
+			fmt.Printf("Warning: %v; falling back to polling every %v\n", err, opts.Interval)
+		}
+	}
+	if watcher == nil {
+		if watcher, err = newPollWatcher(a.projectPath, ignore, opts.Interval); err != nil {
+			return err
+		}
+	}
+	defer watcher.Close()
+
+	pending := make(map[string]bool)
+	var quiet <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-watcher.Errors():
+			fmt.Printf("Warning: %v\n", err)
+		case path, ok := <-watcher.Events():
+			if !ok {
+				return fmt.Errorf("file watcher stopped unexpectedly")
+			}
+			pending[path] = true
+			quiet = time.After(opts.Debounce)
+		case <-quiet:
+			changed := make([]string, 0, len(pending))
+			for path := range pending {
+				changed = append(changed, path)
+			}
+			sort.Strings(changed)
+			pending = make(map[string]bool)
+			quiet = nil
+
+			for _, workflow := range workflows {
+				matched := matchingFiles(workflow.Trigger.Pattern, changed)
+				if len(matched) == 0 {
+					continue
+				}
+				result, err := a.RunWorkflow(ctx, workflow.Name, RunOptions{
+					Trigger: "file_change",
+					Env:     map[string]string{ChangedFilesEnv: strings.Join(matched, "\n")},
+				})
+				if opts.OnResult != nil {
+					opts.OnResult(workflow.Name, result, err)
+				}
+			}
+		}
+	}
+}
+
+// watchedWorkflows returns the named workflows, or every file_change
+// workflow when no names are given, sorted by name
+func (a *AutomationService) watchedWorkflows(names []string) ([]*Workflow, error) {
+	var workflows []*Workflow
+	if len(names) > 0 {
+		for _, name := range names {
+			workflow, err := a.GetWorkflow(name)
+			if err != nil {
+				return nil, err
+			}
+			workflows = append(workflows, workflow)
+		}
+	} else {
+		for _, workflow := range a.workflows {
+			if workflow.Trigger.Type == "file_change" {
+				workflows = append(workflows, workflow)
+			}
+		}
+	}
+
+	if len(workflows) == 0 {
+		return nil, fmt.Errorf("no file_change workflows to watch")
+	}
+	sort.Slice(workflows, func(i, j int) bool { return workflows[i].Name < workflows[j].Name })
+	return workflows, nil
+}
+
+// matchingFiles returns the paths matching a trigger pattern; an empty
+// pattern matches every path
+func matchingFiles(pattern string, paths []string) []string {
+	if pattern == "" {
+		return paths
+	}
+	var matched []string
+	for _, path := range paths {
+		if MatchGlob(pattern, path) {
+			matched = append(matched, path)
+		}
+	}
+	return matched
+}
+
+// pollWatcher detects changes by comparing periodic scans of the tree
+type pollWatcher struct {
+	root     string
+	ignore   *IgnoreMatcher
+	interval time.Duration
+	events   chan string
+	errors   chan error
+	done     chan struct{}
+	once     sync.Once
+}
+
+// fileState is what a scan records about a file
+type fileState struct {
+	modTime time.Time
+	size    int64
+	mode    fs.FileMode
+}
+
+func newPollWatcher(root string, ignore *IgnoreMatcher, interval time.Duration) (*pollWatcher, error) {
+	w := &pollWatcher{
+		root:     root,
+		ignore:   ignore,
+		interval: interval,
+		events:   make(chan string, 256),
+		errors:   make(chan error, 1),
+		done:     make(chan struct{}),
+	}
+	snapshot, err := w.scan()
+	if err != nil {
+		return nil, err
+	}
+	go w.run(snapshot)
+	return w, nil
+}
+
+func (w *pollWatcher) Events() <-chan string { return w.events }
+
+func (w *pollWatcher) Errors() <-chan error { return w.errors }
+
+func (w *pollWatcher) Close() error {
+	w.once.Do(func() { close(w.done) })
+	return nil
+}
+
+func (w *pollWatcher) run(previous map[string]fileState) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+
+		current, err := w.scan()
+		if err != nil {
+			select {
+			case w.errors <- err:
+			default:
+			}
+			continue
+		}
+
+		var changed []string
+		for path, state := range current {
+			if old, ok := previous[path]; !ok || old != state {
+				changed = append(changed, path)
+			}
+		}
+		for path := range previous {
+			if _, ok := current[path]; !ok {
+				changed = append(changed, path)
+			}
+		}
+		sort.Strings(changed)
+		previous = current
+
+		for _, path := range changed {
+			select {
+			case w.events <- path:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// scan records the state of every file that is not ignored
+func (w *pollWatcher) scan() (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	err := filepath.WalkDir(w.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == w.root {
+				return err
+			}
+			return nil // the entry vanished or cannot be read
+		}
+		rel, err := filepath.Rel(w.root, path)
+		if err != nil || rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if w.ignore.Ignored(rel, entry.IsDir()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		files[rel] = fileState{modTime: info.ModTime(), size: info.Size(), mode: info.Mode()}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", w.root, err)
+	}
+	return files, nil
+}
+
+// walkDirs calls fn with every directory below root/rel, including rel
+// itself, that is not ignored, and with every file found in them
+func walkDirs(root, rel string, ignore *IgnoreMatcher, fn func(rel string, isDir bool) error) error {
+	start := filepath.Join(root, filepath.FromSlash(rel))
+	return filepath.WalkDir(start, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path != start {
+				return nil
+			}
+			return err
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if relPath != "." && ignore.Ignored(relPath, entry.IsDir()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return fn(relPath, entry.IsDir())
+	})
+}
@@ -0,0 +1,176 @@
+package automation
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// inotifyMask selects the events that count as a change
+const inotifyMask = syscall.IN_CREATE | syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE |
+	syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+// inotifyWatcher watches every directory of the tree with inotify
+type inotifyWatcher struct {
+	root   string
+	ignore *IgnoreMatcher
+	fd     int
+	file   *os.File
+	events chan string
+	errors chan error
+	done   chan struct{}
+	once   sync.Once
+
+	mu      sync.Mutex
+	watches map[int32]string // watch descriptor to directory, relative to root
+}
+
+func newNativeWatcher(root string, ignore *IgnoreMatcher) (fileWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+
+	w := &inotifyWatcher{
+		root:    root,
+		ignore:  ignore,
+		fd:      fd,
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		events:  make(chan string, 256),
+		errors:  make(chan error, 1),
+		done:    make(chan struct{}),
+		watches: make(map[int32]string),
+	}
+	if _, err := w.addTree("."); err != nil {
+		w.file.Close()
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+func (w *inotifyWatcher) Events() <-chan string { return w.events }
+
+func (w *inotifyWatcher) Errors() <-chan error { return w.errors }
+
+// Close stops the watcher; the pending read returns and run exits
+func (w *inotifyWatcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		err = w.file.Close()
+	})
+	return err
+}
+
+// addTree watches rel and every directory below it, returning the files
+// already present so changes made before the watch was added are not lost
+func (w *inotifyWatcher) addTree(rel string) ([]string, error) {
+	var files []string
+	err := walkDirs(w.root, rel, w.ignore, func(rel string, isDir bool) error {
+		if !isDir {
+			files = append(files, rel)
+			return nil
+		}
+
+		wd, err := syscall.InotifyAddWatch(w.fd, filepath.Join(w.root, filepath.FromSlash(rel)), inotifyMask)
+		if err != nil {
+			if errors.Is(err, syscall.ENOENT) {
+				return nil
+			}
+			if errors.Is(err, syscall.ENOSPC) {
+				return fmt.Errorf("inotify watch limit reached at %s (raise fs.inotify.max_user_watches)", rel)
+			}
+			return fmt.Errorf("failed to watch %s: %w", rel, err)
+		}
+		w.mu.Lock()
+		w.watches[int32(wd)] = rel
+		w.mu.Unlock()
+		return nil
+	})
+	return files, err
+}
+
+func (w *inotifyWatcher) run() {
+	defer close(w.events)
+
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				w.report(fmt.Errorf("failed to read file events: %w", err))
+			}
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			wd := int32(binary.NativeEndian.Uint32(buf[offset:]))
+			mask := binary.NativeEndian.Uint32(buf[offset+4:])
+			length := int(binary.NativeEndian.Uint32(buf[offset+12:]))
+			start := offset + syscall.SizeofInotifyEvent
+			name := strings.TrimRight(string(buf[start:start+length]), "\x00")
+			offset = start + length
+
+			w.handle(wd, mask, name)
+		}
+	}
+}
+
+// handle turns one inotify event into changed paths
+func (w *inotifyWatcher) handle(wd int32, mask uint32, name string) {
+	if mask&syscall.IN_Q_OVERFLOW != 0 {
+		w.report(errors.New("too many file events at once; some changes were missed"))
+		return
+	}
+
+	w.mu.Lock()
+	dir, ok := w.watches[wd]
+	if mask&syscall.IN_IGNORED != 0 {
+		delete(w.watches, wd)
+	}
+	w.mu.Unlock()
+	if !ok || name == "" {
+		return
+	}
+
+	rel := path.Join(dir, name)
+	isDir := mask&syscall.IN_ISDIR != 0
+	if w.ignore.Ignored(rel, isDir) {
+		return
+	}
+
+	if isDir && mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+		files, err := w.addTree(rel)
+		if err != nil {
+			w.report(err)
+		}
+		for _, file := range files {
+			w.emit(file)
+		}
+		return
+	}
+	w.emit(rel)
+}
+
+// emit passes a changed path on unless the watcher has been closed
+func (w *inotifyWatcher) emit(rel string) {
+	select {
+	case w.events <- rel:
+	case <-w.done:
+	}
+}
+
+// report passes a non-fatal error on without blocking
+func (w *inotifyWatcher) report(err error) {
+	select {
+	case w.errors <- err:
+	default:
+	}
+}
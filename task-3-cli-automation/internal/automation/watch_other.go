@@ -0,0 +1,10 @@
+//go:build !linux
+
+package automation
+
+import "errors"
+
+// newNativeWatcher is only implemented on Linux; elsewhere Watch polls
+func newNativeWatcher(root string, ignore *IgnoreMatcher) (fileWatcher, error) {
+	return nil, errors.New("native file watching is not supported on this platform")
+}
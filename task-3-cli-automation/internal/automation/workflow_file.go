@@ -0,0 +1,425 @@
+package automation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// triggerTypes lists the supported workflow trigger types
+var triggerTypes = []string{"manual", "file_change", "git_hook", "schedule"}
+
+// yamlLinePattern finds the line number in errors reported by the YAML decoder
+var yamlLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// WorkflowError describes a problem at a specific line of a workflow file
+type WorkflowError struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (e *WorkflowError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.File, e.Message)
+}
+
+// WorkflowErrors collects every problem found in a workflow file
+type WorkflowErrors []*WorkflowError
+
+func (e WorkflowErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// LoadWorkflowFile reads and validates a workflow definition. When the file
+// has no name the file name is used.
+func LoadWorkflowFile(path string) (*Workflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workflow: %w", err)
+	}
+
+	workflow, err := ParseWorkflow(data, path)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if workflow.Name == "" {
+		workflow.Name = name
+	} else if workflow.Name != name {
+		return nil, &WorkflowError{File: path, Line: 1, Message: fmt.Sprintf("workflow name %q does not match file name %q", workflow.Name, name)}
+	}
+	workflow.Source = path
+	return workflow, nil
+}
+
+// ParseWorkflow decodes a workflow from YAML, rejecting unknown fields.
+// Errors carry the source name and line number.
+func ParseWorkflow(data []byte, source string) (*Workflow, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, decodeError(source, err)
+	}
+	if len(root.Content) == 0 {
+		return nil, &WorkflowError{File: source, Message: "workflow file is empty"}
+	}
+	if root.Content[0].Kind != yaml.MappingNode {
+		return nil, &WorkflowError{File: source, Line: root.Content[0].Line, Message: "workflow must be a mapping"}
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var workflow Workflow
+	if err := decoder.Decode(&workflow); err != nil && err != io.EOF {
+		return nil, decodeError(source, err)
+	}
+
+	if problems := validateWorkflow(&workflow, root.Content[0], source); len(problems) > 0 {
+		return nil, problems
+	}
+
+	if workflow.Trigger.Type == "" {
+		workflow.Trigger.Type = "manual"
+	}
+	if workflow.Environment == nil {
+		workflow.Environment = make(map[string]string)
+	}
+	return &workflow, nil
+}
+
+// MarshalWorkflow encodes a workflow as YAML with two-space indentation
+func MarshalWorkflow(workflow *Workflow) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(workflow); err != nil {
+		return nil, fmt.Errorf("failed to encode workflow %s: %w", workflow.Name, err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode workflow %s: %w", workflow.Name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// validateWorkflow checks the decoded workflow, using the node tree to
+// report the line of each problem
+func validateWorkflow(workflow *Workflow, root *yaml.Node, source string) WorkflowErrors {
+	var problems WorkflowErrors
+	report := func(node *yaml.Node, format string, args ...interface{}) {
+		line := root.Line
+		if node != nil {
+			line = node.Line
+		}
+		problems = append(problems, &WorkflowError{File: source, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	listItem := func(list *yaml.Node, i int) *yaml.Node {
+		if list != nil && list.Kind == yaml.SequenceNode && i < len(list.Content) {
+			return list.Content[i]
+		}
+		return list
+	}
+
+	// checkRun reports problems with the fields that control how a step runs
+	checkRun := func(node *yaml.Node, label string, step WorkflowStep) {
+		if step.Timeout < 0 {
+			report(nodeValue(node, "timeout"), "%s timeout must not be negative, got %v", label, step.Timeout)
+		}
+		if step.Retry == nil {
+			return
+		}
+		retry := nodeValue(node, "retry")
+		if step.Retry.Attempts < 1 {
+			report(retry, "%s retry attempts must be at least 1, got %d", label, step.Retry.Attempts)
+		}
+		if step.Retry.Backoff < 0 {
+			report(nodeValue(retry, "backoff"), "%s retry backoff must not be negative, got %v", label, step.Retry.Backoff)
+		}
+		for j, code := range step.Retry.OnExitCodes {
+			if code < 1 || code > 255 {
+				report(listItem(nodeValue(retry, "on_exit_codes"), j), "%s retry exit code %d is out of range 1-255", label, code)
+			}
+		}
+	}
+
+	trigger := nodeValue(root, "trigger")
+	if workflow.Trigger.Type != "" && !containsString(triggerTypes, workflow.Trigger.Type) {
+		report(nodeValue(trigger, "type"), "unknown trigger type %q (expected one of %s)",
+			workflow.Trigger.Type, strings.Join(triggerTypes, ", "))
+	}
+
+	if workflow.Trigger.Type == "schedule" {
+		if strings.TrimSpace(workflow.Trigger.Pattern) == "" {
+			report(trigger, "schedule trigger needs a cron expression in pattern")
+		} else if _, err := ParseSchedule(workflow.Trigger.Pattern); err != nil {
+			report(nodeValue(trigger, "pattern"), "%v", err)
+		}
+	}
+
+	if workflow.Parallelism < 0 {
+		report(nodeValue(root, "parallelism"), "parallelism must not be negative, got %d", workflow.Parallelism)
+	}
+	if workflow.Timeout < 0 {
+		report(nodeValue(root, "timeout"), "timeout must not be negative, got %v", workflow.Timeout)
+	}
+
+	steps := nodeValue(root, "steps")
+	stepNode := func(i int) *yaml.Node {
+		if steps != nil && i < len(steps.Content) {
+			return steps.Content[i]
+		}
+		return nil
+	}
+	if len(workflow.Steps) == 0 {
+		report(steps, "workflow has no steps")
+	}
+	for i, step := range workflow.Steps {
+		node := stepNode(i)
+
+		if step.Uses != "" {
+			if step.Name == "" {
+				workflow.Steps[i].Name = StepID(i, step)
+				if step.ID == "" {
+					workflow.Steps[i].Name = step.Uses
+				}
+			}
+			label := fmt.Sprintf("step %d (%s)", i+1, workflow.Steps[i].Name)
+			if err := validUses(step.Uses); err != nil {
+				report(nodeValue(node, "uses"), "%s: %v", label, err)
+			}
+			// a uses: step only passes inputs; what runs comes from the template
+			for _, field := range []string{"command", "args", "working_dir", "environment", "timeout", "retry"} {
+				if value := nodeValue(node, field); value != nil {
+					report(value, "%s cannot set %s together with uses", label, field)
+				}
+			}
+			continue
+		}
+
+		if strings.TrimSpace(step.Command) == "" {
+			report(node, "step %d (%s) has no command", i+1, step.Name)
+		}
+		if step.Name == "" {
+			workflow.Steps[i].Name = fmt.Sprintf("Step %d", i+1)
+		}
+		if len(step.With) > 0 {
+			report(nodeValue(node, "with"), "step %d (%s) sets with but no uses", i+1, workflow.Steps[i].Name)
+		}
+		checkRun(node, fmt.Sprintf("step %d (%s)", i+1, workflow.Steps[i].Name), step)
+	}
+
+	// on_failure and always steps run in order after the workflow's steps
+	handlers := []struct {
+		phase string
+		steps []WorkflowStep
+	}{
+		{"on_failure", workflow.OnFailure},
+		{"always", workflow.Always},
+	}
+	for _, handler := range handlers {
+		for i, step := range handler.steps {
+			node := listItem(nodeValue(root, handler.phase), i)
+			if step.Name == "" {
+				handler.steps[i].Name = HandlerStepID(handler.phase, i, step)
+			}
+			label := fmt.Sprintf("%s step %d (%s)", handler.phase, i+1, handler.steps[i].Name)
+
+			if strings.TrimSpace(step.Command) == "" {
+				report(node, "%s has no command", label)
+			}
+			if len(step.Needs) > 0 {
+				report(nodeValue(node, "needs"), "%s cannot use needs; %s steps run in order after all other steps", label, handler.phase)
+			}
+			if step.Uses != "" {
+				report(nodeValue(node, "uses"), "%s cannot use templates", label)
+			}
+			checkRun(node, label, step)
+		}
+	}
+
+	graph, err := buildStepGraph(workflow.Steps)
+	var graphErr *graphError
+	if errors.As(err, &graphErr) {
+		node := stepNode(graphErr.step)
+		if needs := nodeValue(node, "needs"); needs != nil {
+			node = needs
+		}
+		report(node, "%s", graphErr.message)
+		return problems
+	}
+
+	// check parses a template or condition and verifies its step references;
+	// step is -1 for workflow-level fields, which run before any step, and
+	// len(graph.ids) for handlers, which run after all of them
+	check := func(node *yaml.Node, step int, value string, condition bool) {
+		var refs [][]string
+		if condition {
+			e, err := parseCondition(value)
+			if err != nil {
+				report(node, "%v", err)
+				return
+			}
+			refs = exprRefs(e)
+		} else {
+			t, err := parseTemplate(value)
+			if err != nil {
+				report(node, "%v", err)
+				return
+			}
+			refs = t.refs()
+		}
+		if err := checkStepRefs(refs, graph, step); err != nil {
+			report(node, "%v", err)
+		}
+		for _, ref := range refs {
+			if _, declared := workflow.Inputs[ref[1]]; ref[0] == "inputs" && !declared {
+				report(node, "%s refers to undeclared input %q", strings.Join(ref, "."), ref[1])
+			}
+		}
+	}
+
+	// handler ids share the namespace of the workflow's steps
+	ids := make(map[string]string, len(graph.ids))
+	for _, id := range graph.ids {
+		ids[id] = "a workflow step"
+	}
+	for _, handler := range handlers {
+		for i, step := range handler.steps {
+			id := HandlerStepID(handler.phase, i, step)
+			if owner, exists := ids[id]; exists {
+				report(listItem(nodeValue(root, handler.phase), i), "step id %q is already used by %s", id, owner)
+				continue
+			}
+			ids[id] = fmt.Sprintf("%s step %d", handler.phase, i+1)
+		}
+	}
+
+	if workflow.Trigger.Type == "git_hook" {
+		for i, event := range workflow.Trigger.Events {
+			if _, _, err := ParseEvent(event); err != nil {
+				report(listItem(nodeValue(trigger, "events"), i), "%v", err)
+			}
+		}
+	}
+
+	for name, value := range workflow.Environment {
+		check(nodeValue(nodeValue(root, "environment"), name), -1, value, false)
+	}
+	for i, condition := range workflow.Trigger.Conditions {
+		check(listItem(nodeValue(trigger, "conditions"), i), -1, condition, true)
+	}
+	for i, step := range workflow.Steps {
+		node := stepNode(i)
+		check(nodeValue(node, "command"), i, step.Command, false)
+		for j, arg := range step.Args {
+			check(listItem(nodeValue(node, "args"), j), i, arg, false)
+		}
+		for name, value := range step.Environment {
+			check(nodeValue(nodeValue(node, "environment"), name), i, value, false)
+		}
+		for name, value := range step.With {
+			check(nodeValue(nodeValue(node, "with"), name), i, value, false)
+		}
+		if step.If != "" {
+			check(nodeValue(node, "if"), i, step.If, true)
+		}
+	}
+	for _, handler := range handlers {
+		for i, step := range handler.steps {
+			node := listItem(nodeValue(root, handler.phase), i)
+			check(nodeValue(node, "command"), len(graph.ids), step.Command, false)
+			for j, arg := range step.Args {
+				check(listItem(nodeValue(node, "args"), j), len(graph.ids), arg, false)
+			}
+			for name, value := range step.Environment {
+				check(nodeValue(nodeValue(node, "environment"), name), len(graph.ids), value, false)
+			}
+			if step.If != "" {
+				check(nodeValue(node, "if"), len(graph.ids), step.If, true)
+			}
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	return problems
+}
+
+// decodeError converts a YAML decoder error into WorkflowErrors with line numbers
+func decodeError(source string, err error) error {
+	var messages []string
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	} else {
+		messages = []string{err.Error()}
+	}
+
+	problems := make(WorkflowErrors, 0, len(messages))
+	for _, message := range messages {
+		problem := &WorkflowError{File: source, Message: strings.TrimPrefix(message, "yaml: ")}
+		if match := yamlLinePattern.FindStringSubmatch(message); match != nil {
+			problem.Line, _ = strconv.Atoi(match[1])
+			problem.Message = match[2]
+		}
+		problems = append(problems, problem)
+	}
+	return problems
+}
+
+// stepLine returns the line of the index-th step in a workflow file, or 0
+// when it cannot be found
+func stepLine(path string, index int) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	var root yaml.Node
+	if yaml.Unmarshal(data, &root) != nil || len(root.Content) == 0 {
+		return 0
+	}
+	if steps := nodeValue(root.Content[0], "steps"); steps != nil && index < len(steps.Content) {
+		return steps.Content[index].Line
+	}
+	return 0
+}
+
+// nodeValue returns the value stored under key in a mapping node, or nil
+func nodeValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// containsString reports whether list holds value
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+package chat
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/ai"
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/git"
+)
+
+// SendFunc sends a request to the AI service and is responsible for printing the answer
+type SendFunc func(ctx context.Context, request *ai.Request) (*ai.Response, error)
+
+// REPL runs an interactive chat session
+type REPL struct {
+	in      *bufio.Scanner
+	out     io.Writer
+	send    SendFunc
+	store   *SessionStore
+	session *Session
+	git     *git.GitService
+	pending []ai.FileInfo
+	inputs  []string
+}
+
+// NewREPL creates a new REPL for the given session
+func NewREPL(in io.Reader, out io.Writer, send SendFunc, store *SessionStore, session *Session, gitService *git.GitService) *REPL {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	// Seed the input history from a resumed conversation
+	var inputs []string
+	for _, msg := range session.Messages {
+		if msg.Role == "user" {
+			inputs = append(inputs, msg.Content)
+		}
+	}
+
+	return &REPL{
+		in:      scanner,
+		out:     out,
+		send:    send,
+		store:   store,
+		session: session,
+		git:     gitService,
+		inputs:  inputs,
+	}
+}
+
+// Run reads input until EOF or /exit, sending each message to the AI service
+func (r *REPL) Run(ctx context.Context) error {
+	fmt.Fprintf(r.out, "💬 Chat session %s (model: %s)\n", r.session.ID, r.displayModel())
+	fmt.Fprintln(r.out, "End a line with \\ or wrap text in \"\"\" for multi-line input. Type /help for commands.")
+
+	for {
+		input, ok := r.readInput()
+		if !ok {
+			fmt.Fprintln(r.out)
+			return r.save()
+		}
+
+		input = strings.TrimSpace(input)
+		if input == "" {
+			continue
+		}
+		r.inputs = append(r.inputs, input)
+
+		if strings.HasPrefix(input, "/") {
+			exit, err := r.handleCommand(input)
+			if err != nil {
+				fmt.Fprintf(r.out, "Error: %v\n", err)
+			}
+			if exit {
+				return r.save()
+			}
+			continue
+		}
+
+		if err := r.sendMessage(ctx, input); err != nil {
+			fmt.Fprintf(r.out, "Error: %v\n", err)
+		}
+	}
+}
+
+// readInput reads one logical message, joining continuation lines and """ blocks
+func (r *REPL) readInput() (string, bool) {
+	fmt.Fprint(r.out, "> ")
+	if !r.in.Scan() {
+		return "", false
+	}
+	line := r.in.Text()
+
+	// Triple-quoted block
+	if strings.TrimSpace(line) == `"""` {
+		var lines []string
+		for {
+			fmt.Fprint(r.out, "… ")
+			if !r.in.Scan() {
+				return strings.Join(lines, "\n"), len(lines) > 0
+			}
+			next := r.in.Text()
+			if strings.TrimSpace(next) == `"""` {
+				return strings.Join(lines, "\n"), true
+			}
+			lines = append(lines, next)
+		}
+	}
+
+	// Backslash continuation
+	var lines []string
+	for strings.HasSuffix(line, `\`) {
+		lines = append(lines, strings.TrimSuffix(line, `\`))
+		fmt.Fprint(r.out, "… ")
+		if !r.in.Scan() {
+			return strings.Join(lines, "\n"), true
+		}
+		line = r.in.Text()
+	}
+	lines = append(lines, line)
+
+	return strings.Join(lines, "\n"), true
+}
+
+// sendMessage sends a user message with any pending attachments
+func (r *REPL) sendMessage(ctx context.Context, message string) error {
+	request := ai.NewRequest(ai.RequestChat, r.session.Prompt(message))
+	request.Model = r.session.Model
+	request.AttachFiles(r.pending...)
+
+	var files []string
+	for _, file := range r.pending {
+		files = append(files, file.Path)
+	}
+
+	response, err := r.send(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	r.pending = nil
+	r.session.AddMessage("user", message, files)
+	r.session.AddMessage("assistant", response.Content, nil)
+	return r.save()
+}
+
+// handleCommand executes a slash command and reports whether the REPL should exit
+func (r *REPL) handleCommand(input string) (bool, error) {
+	fields := strings.Fields(input)
+	command, args := fields[0], fields[1:]
+
+	switch command {
+	case "/exit", "/quit":
+		return true, nil
+
+	case "/help":
+		fmt.Fprintln(r.out, "Commands:")
+		fmt.Fprintln(r.out, "  /file <path>   attach a file to the next message")
+		fmt.Fprintln(r.out, "  /diff [range]  attach the git diff (staged changes by default)")
+		fmt.Fprintln(r.out, "  /clear         clear the conversation and pending attachments")
+		fmt.Fprintln(r.out, "  /save          save the session")
+		fmt.Fprintln(r.out, "  /model [name]  show or switch the model")
+		fmt.Fprintln(r.out, "  /history       show the inputs entered in this session")
+		fmt.Fprintln(r.out, "  /exit          save and leave the session")
+
+	case "/file":
+		if len(args) == 0 {
+			return false, fmt.Errorf("usage: /file <path>")
+		}
+		for _, path := range args {
+			file, err := ai.FileFromPath(path)
+			if err != nil {
+				return false, err
+			}
+			r.pending = append(r.pending, file)
+			fmt.Fprintf(r.out, "📎 Attached %s (%d bytes)\n", path, file.Size)
+		}
+
+	case "/diff":
+		if r.git == nil || !r.git.IsGitRepo() {
+			return false, fmt.Errorf("not in a git repository")
+		}
+		diffRange := ""
+		if len(args) > 0 {
+			diffRange = args[0]
+		}
+		diff, err := r.git.GetDiff(diffRange)
+		if err != nil {
+			return false, err
+		}
+		if diff == "" {
+			fmt.Fprintln(r.out, "No changes to attach")
+			return false, nil
+		}
+		r.pending = append(r.pending, ai.FileInfo{Path: "git.diff", Content: diff, Language: "diff", Size: len(diff)})
+		fmt.Fprintf(r.out, "📎 Attached diff (%d bytes)\n", len(diff))
+
+	case "/clear":
+		r.session.Clear()
+		r.pending = nil
+		fmt.Fprintln(r.out, "Conversation cleared")
+
+	case "/save":
+		if err := r.store.Save(r.session); err != nil {
+			return false, err
+		}
+		fmt.Fprintf(r.out, "💾 Session saved: %s (resume with 'k3ss-ai chat --resume %s')\n", r.session.ID, r.session.ID)
+
+	case "/model":
+		if len(args) == 0 {
+			fmt.Fprintf(r.out, "Model: %s\n", r.displayModel())
+			return false, nil
+		}
+		r.session.Model = args[0]
+		fmt.Fprintf(r.out, "Model switched to %s\n", args[0])
+
+	case "/history":
+		for i, input := range r.inputs[:len(r.inputs)-1] {
+			fmt.Fprintf(r.out, "%3d  %s\n", i+1, strings.ReplaceAll(input, "\n", "\n     "))
+		}
+
+	default:
+		return false, fmt.Errorf("unknown command %s (try /help)", command)
+	}
+
+	return false, nil
+}
+
+// save persists the session if it has any messages
+func (r *REPL) save() error {
+	if len(r.session.Messages) == 0 {
+		return nil
+	}
+	return r.store.Save(r.session)
+}
+
+// displayModel returns the session model or a placeholder for the service default
+func (r *REPL) displayModel() string {
+	if r.session.Model == "" {
+		return "default"
+	}
+	return r.session.Model
+}
@@ -0,0 +1,165 @@
+package chat
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Message represents a single turn in a conversation
+type Message struct {
+	Role    string    `json:"role"` // "user" or "assistant"
+	Content string    `json:"content"`
+	Files   []string  `json:"files,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// Session represents a persisted chat conversation
+type Session struct {
+	ID       string    `json:"id"`
+	Model    string    `json:"model,omitempty"`
+	Created  time.Time `json:"created"`
+	Updated  time.Time `json:"updated"`
+	Messages []Message `json:"messages"`
+}
+
+// NewSession creates a new empty session using the given model
+func NewSession(model string) *Session {
+	now := time.Now()
+	return &Session{
+		ID:       newSessionID(now),
+		Model:    model,
+		Created:  now,
+		Updated:  now,
+		Messages: []Message{},
+	}
+}
+
+// newSessionID names a session after its start time, with a random suffix
+// so sessions started in the same second do not overwrite each other
+func newSessionID(start time.Time) string {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return start.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// AddMessage appends a message to the conversation
+func (s *Session) AddMessage(role, content string, files []string) {
+	now := time.Now()
+	s.Messages = append(s.Messages, Message{
+		Role:    role,
+		Content: content,
+		Files:   files,
+		Time:    now,
+	})
+	s.Updated = now
+}
+
+// Clear removes all messages from the conversation
+func (s *Session) Clear() {
+	s.Messages = []Message{}
+	s.Updated = time.Now()
+}
+
+// Prompt renders the conversation so far followed by the new message, so the
+// AI service sees earlier turns as context
+func (s *Session) Prompt(message string) string {
+	if len(s.Messages) == 0 {
+		return message
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("Conversation so far:\n")
+	for _, msg := range s.Messages {
+		prompt.WriteString(fmt.Sprintf("[%s]\n%s\n\n", msg.Role, msg.Content))
+	}
+	prompt.WriteString("[user]\n")
+	prompt.WriteString(message)
+	return prompt.String()
+}
+
+// SessionStore persists sessions under .k3ss-ai/sessions
+type SessionStore struct {
+	dir string
+}
+
+// NewSessionStore creates a session store for the given project
+func NewSessionStore(projectPath string) *SessionStore {
+	if projectPath == "" {
+		projectPath = "."
+	}
+	return &SessionStore{dir: filepath.Join(projectPath, ".k3ss-ai", "sessions")}
+}
+
+// Save writes a session to disk
+func (s *SessionStore) Save(session *Session) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	return os.WriteFile(s.path(session.ID), data, 0600)
+}
+
+// Load reads a session from disk
+func (s *SessionStore) Load(id string) (*Session, error) {
+	data, err := os.ReadFile(s.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("session '%s' not found", id)
+		}
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to parse session %s: %w", id, err)
+	}
+	if session.Messages == nil {
+		session.Messages = []Message{}
+	}
+	return &session, nil
+}
+
+// List returns all stored sessions, most recently updated first
+func (s *SessionStore) List() ([]*Session, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read session directory: %w", err)
+	}
+
+	var sessions []*Session
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		session, err := s.Load(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Updated.After(sessions[j].Updated)
+	})
+	return sessions, nil
+}
+
+// path returns the file path for a session ID
+func (s *SessionStore) path(id string) string {
+	return filepath.Join(s.dir, filepath.Base(id)+".json")
+}
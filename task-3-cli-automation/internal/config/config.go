@@ -4,75 +4,134 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/credentials"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the application configuration
 type Config struct {
+	// Schema version of the configuration file
+	Version int `yaml:"version" json:"version"`
+
+	// Name of the AI profile used by default
+	Profile string `yaml:"profile" json:"profile"`
+
 	// AI Service Configuration
-	AI AIConfig `yaml:"ai"`
+	AI AIConfig `yaml:"ai" json:"ai"`
+
+	// Named AI profiles layered over the ai block
+	Profiles map[string]*AIConfig `yaml:"profiles,omitempty" json:"profiles,omitempty"`
+
+	// Per-command AI overrides, keyed by command path such as "git commit"
+	Routing map[string]*Route `yaml:"routing,omitempty" json:"routing,omitempty"`
 	
 	// Git Configuration
-	Git GitConfig `yaml:"git"`
+	Git GitConfig `yaml:"git" json:"git"`
 	
 	// Build Configuration
-	Build BuildConfig `yaml:"build"`
+	Build BuildConfig `yaml:"build" json:"build"`
+	
+	// Workflow Configuration
+	Workflows WorkflowsConfig `yaml:"workflows" json:"workflows"`
+	
+	// Batch Operation Configuration
+	Batch BatchConfig `yaml:"batch" json:"batch"`
+	
+	// Step Sandbox Configuration
+	Sandbox SandboxConfig `yaml:"sandbox" json:"sandbox"`
 	
 	// General Settings
-	Settings GeneralSettings `yaml:"settings"`
+	Settings GeneralSettings `yaml:"settings" json:"settings"`
 }
 
+// AIConfig holds the AI service settings. It is used both for the ai block
+// and for named profiles, where empty fields inherit from the ai block.
 type AIConfig struct {
 	// API endpoint for AI orchestration service
-	Endpoint string `yaml:"endpoint"`
+	Endpoint string `yaml:"endpoint,omitempty" json:"endpoint,omitempty"`
 	
 	// API key for authentication
-	APIKey string `yaml:"api_key"`
+	APIKey string `yaml:"api_key,omitempty" json:"api_key,omitempty"`
 	
 	// Default model to use
-	Model string `yaml:"model"`
+	Model string `yaml:"model,omitempty" json:"model,omitempty"`
 	
 	// Timeout for AI requests (seconds)
-	Timeout int `yaml:"timeout"`
+	Timeout int `yaml:"timeout,omitempty" json:"timeout,omitempty"`
 }
 
 type GitConfig struct {
 	// Auto-generate commit messages
-	AutoCommit bool `yaml:"auto_commit"`
+	AutoCommit bool `yaml:"auto_commit" json:"auto_commit"`
 	
 	// Default commit message style
-	CommitStyle string `yaml:"commit_style"`
+	CommitStyle string `yaml:"commit_style" json:"commit_style"`
 	
 	// Enable pre-commit hooks
-	PreCommitHooks bool `yaml:"pre_commit_hooks"`
+	PreCommitHooks bool `yaml:"pre_commit_hooks" json:"pre_commit_hooks"`
 }
 
 type BuildConfig struct {
 	// Auto-analyze build failures
-	AutoAnalyze bool `yaml:"auto_analyze"`
+	AutoAnalyze bool `yaml:"auto_analyze" json:"auto_analyze"`
 	
 	// Build command to use
-	Command string `yaml:"command"`
+	Command string `yaml:"command" json:"command"`
 	
 	// Performance monitoring
-	MonitorPerformance bool `yaml:"monitor_performance"`
+	MonitorPerformance bool `yaml:"monitor_performance" json:"monitor_performance"`
+}
+
+type WorkflowsConfig struct {
+	// Number of runs kept per workflow in .k3ss-ai/runs (0 keeps all)
+	KeepRuns int `yaml:"keep_runs" json:"keep_runs"`
+	
+	// Days a run is kept before it is pruned (0 keeps runs of any age)
+	KeepDays int `yaml:"keep_days" json:"keep_days"`
+}
+
+type BatchConfig struct {
+	// Lint-fix commands by file extension, e.g. ".go=golangci-lint run --fix {dir}; .py=ruff check --fix {file}"
+	Linters string `yaml:"linters" json:"linters"`
+}
+
+type SandboxConfig struct {
+	// Run workflow and batch steps in the sandbox
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	
+	// CPU time limit of each step process (seconds, 0 for no limit)
+	CPUTime int `yaml:"cpu_time" json:"cpu_time"`
+	
+	// Address space limit of each step process (MB, 0 for no limit)
+	Memory int `yaml:"memory" json:"memory"`
+	
+	// Open file limit of each step process (0 for no limit)
+	OpenFiles int `yaml:"open_files" json:"open_files"`
+	
+	// Comma-separated host environment variables steps see; a trailing * matches any suffix
+	EnvAllowlist string `yaml:"env_allowlist" json:"env_allowlist"`
+	
+	// Comma-separated paths mounted read-only while a step runs, relative to the project
+	ReadOnlyPaths string `yaml:"read_only_paths" json:"read_only_paths"`
 }
 
 type GeneralSettings struct {
 	// Verbose output
-	Verbose bool `yaml:"verbose"`
+	Verbose bool `yaml:"verbose" json:"verbose"`
 	
 	// Debug mode
-	Debug bool `yaml:"debug"`
+	Debug bool `yaml:"debug" json:"debug"`
 	
 	// Output format preference
-	OutputFormat string `yaml:"output_format"`
+	OutputFormat string `yaml:"output_format" json:"output_format"`
 }
 
 // DefaultConfig returns a configuration with sensible defaults
 func DefaultConfig() *Config {
 	return &Config{
+		Version: CurrentVersion,
 		AI: AIConfig{
 			Endpoint: "http://localhost:8080",
 			Model:    "gpt-4",
@@ -88,6 +147,17 @@ func DefaultConfig() *Config {
 			Command:           "npm run build",
 			MonitorPerformance: true,
 		},
+		Workflows: WorkflowsConfig{
+			KeepRuns: 50,
+			KeepDays: 30,
+		},
+		Sandbox: SandboxConfig{
+			Enabled:      false,
+			CPUTime:      1800,
+			Memory:       8192,
+			OpenFiles:    1024,
+			EnvAllowlist: "PATH,HOME,USER,LOGNAME,SHELL,TERM,LANG,LC_*,TZ,TMPDIR,GIT_*",
+		},
 		Settings: GeneralSettings{
 			Verbose:      false,
 			Debug:        false,
@@ -96,41 +166,77 @@ func DefaultConfig() *Config {
 	}
 }
 
-// LoadConfig loads configuration from file or creates default
-func LoadConfig(configPath string) (*Config, error) {
-	if configPath == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get home directory: %w", err)
+// SplitList splits a comma-separated setting into its trimmed, non-empty items
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
 		}
-		configPath = filepath.Join(home, ".k3ss-ai.yaml")
 	}
-	
-	// If config file doesn't exist, create default
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		config := DefaultConfig()
-		if err := SaveConfig(config, configPath); err != nil {
-			return nil, fmt.Errorf("failed to create default config: %w", err)
+	return items
+}
+
+// ParseToolMap parses a setting of semicolon-separated ".ext=command"
+// entries into commands keyed by file extension
+func ParseToolMap(value string) (map[string]string, error) {
+	tools := make(map[string]string)
+	for _, entry := range strings.Split(value, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		ext, command, ok := strings.Cut(entry, "=")
+		ext, command = strings.TrimSpace(ext), strings.TrimSpace(command)
+		if !ok || !strings.HasPrefix(ext, ".") || len(ext) < 2 || command == "" {
+			return nil, fmt.Errorf("invalid entry %q (expected .ext=command)", strings.TrimSpace(entry))
 		}
-		return config, nil
+		tools[ext] = command
+	}
+	return tools, nil
+}
+
+// ResolvePath returns configPath, or the default ~/.k3ss-ai.yaml when it is empty
+func ResolvePath(configPath string) (string, error) {
+	if configPath != "" {
+		return configPath, nil
 	}
 	
-	// Load existing config
-	data, err := os.ReadFile(configPath)
+	home, err := os.UserHomeDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".k3ss-ai.yaml"), nil
+}
+
+// LoadConfig loads a single configuration file on top of the defaults.
+// A missing file yields the defaults; nothing is written to disk.
+// Unknown keys and invalid values are reported as a *ValidationError.
+// Use LoadLayered for the merged view of all configuration layers.
+func LoadConfig(configPath string) (*Config, error) {
+	configPath, err := ResolvePath(configPath)
+	if err != nil {
+		return nil, err
 	}
 	
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	config := DefaultConfig()
+	problems, err := applyFile(config, make(Origins), configPath, LayerUser)
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
 	}
 	
-	return &config, nil
+	return config, nil
 }
 
-// SaveConfig saves configuration to file
+// SaveConfig saves configuration to file, readable only by its owner.
+// A plaintext API key is rejected; store a credential reference instead.
 func SaveConfig(config *Config, configPath string) error {
+	if config.AI.APIKey != "" && !credentials.IsReference(config.AI.APIKey) {
+		return fmt.Errorf("refusing to write ai.api_key in plaintext; store it with a credential provider")
+	}
+	config.Version = CurrentVersion
 	data, err := yaml.Marshal(config)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
@@ -142,10 +248,9 @@ func SaveConfig(config *Config, configPath string) error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 	
-	if err := os.WriteFile(configPath, data, 0644); err != nil {
+	if err := os.WriteFile(configPath, data, 0600); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 	
 	return nil
 }
-
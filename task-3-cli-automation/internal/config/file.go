@@ -0,0 +1,179 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/credentials"
+	"gopkg.in/yaml.v3"
+)
+
+// SetFileValue validates value for key and writes it into the YAML file at
+// path, leaving other settings and comments in the file untouched. Secrets
+// are only accepted as credential references, never in plaintext.
+func SetFileValue(path, key, value string) error {
+	if IsSecret(key) && !credentials.IsReference(value) {
+		return fmt.Errorf("refusing to write %s to %s in plaintext; store it with a credential provider", key, path)
+	}
+
+	scratch := DefaultConfig()
+	if err := SetValue(scratch, key, value); err != nil {
+		return err
+	}
+	if err := ValidateKey(scratch, key); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	field, _ := lookupField(scratch, key, true)
+
+	doc, err := readDocument(path)
+	if err != nil {
+		return err
+	}
+
+	node := doc.Content[0]
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		child := mappingValue(node, part)
+		if child == nil || child.Kind != yaml.MappingNode {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(node, part, child)
+		}
+		node = child
+	}
+
+	setMappingValue(node, parts[len(parts)-1], scalarNode(field))
+	return writeDocument(path, doc)
+}
+
+// UnsetFileValue removes key from the YAML file at path so lower layers apply again
+func UnsetFileValue(path, key string) error {
+	if _, err := lookupField(DefaultConfig(), key, true); err != nil {
+		return err
+	}
+
+	doc, err := readDocument(path)
+	if err != nil {
+		return err
+	}
+
+	if removeMappingPath(doc.Content[0], strings.Split(key, ".")) {
+		return writeDocument(path, doc)
+	}
+	return nil
+}
+
+// readDocument parses a YAML file into a document node, returning a mapping
+// document holding only the current schema version when the file does not exist
+func readDocument(path string) (*yaml.Node, error) {
+	doc := &yaml.Node{
+		Kind:    yaml.DocumentNode,
+		Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}},
+	}
+	setVersion(doc.Content[0], CurrentVersion)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return doc, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var parsed yaml.Node
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if parsed.Kind == 0 || len(parsed.Content) == 0 {
+		return doc, nil
+	}
+	if parsed.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse config file: top level must be a mapping")
+	}
+	return &parsed, nil
+}
+
+// writeDocument writes a document node back to disk, readable only by its owner
+func writeDocument(path string, doc *yaml.Node) error {
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		return fmt.Errorf("failed to restrict config file permissions: %w", err)
+	}
+	return nil
+}
+
+// mappingValue returns the value node stored under name in a mapping node
+func mappingValue(mapping *yaml.Node, name string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == name {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue stores value under name in a mapping node, replacing any existing entry
+func setMappingValue(mapping *yaml.Node, name string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == name {
+			value.HeadComment = mapping.Content[i+1].HeadComment
+			value.LineComment = mapping.Content[i+1].LineComment
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name},
+		value,
+	)
+}
+
+// removeMappingPath deletes the entry at path, pruning sections left empty
+func removeMappingPath(mapping *yaml.Node, path []string) bool {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != path[0] {
+			continue
+		}
+
+		if len(path) > 1 {
+			child := mapping.Content[i+1]
+			if child.Kind != yaml.MappingNode || !removeMappingPath(child, path[1:]) {
+				return false
+			}
+			if len(child.Content) > 0 {
+				return true
+			}
+		}
+
+		mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+		return true
+	}
+	return false
+}
+
+// scalarNode encodes a configuration field as a typed YAML scalar
+func scalarNode(field reflect.Value) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Value: fmt.Sprint(field.Interface())}
+	switch field.Kind() {
+	case reflect.Bool:
+		node.Tag = "!!bool"
+	case reflect.Int, reflect.Int64:
+		node.Tag = "!!int"
+	default:
+		node.Tag = "!!str"
+	}
+	return node
+}
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/credentials"
+)
+
+// secretField is the name of every setting whose value must never be displayed,
+// both in the ai block and in profiles
+const secretField = "api_key"
+
+// Keys returns every fixed dotted configuration key, sorted. Entries under
+// profiles and routing are listed by EntryKeys.
+func Keys() []string {
+	var keys []string
+	collectKeys(reflect.TypeOf(Config{}), "", &keys)
+	sort.Strings(keys)
+	return keys
+}
+
+// EntryKeys returns the dotted keys of every profile and routing setting
+// present in config, such as "profiles.local.model" or "routing.review.profile"
+func EntryKeys(config *Config) []string {
+	var keys []string
+	for _, name := range config.ProfileNames() {
+		collectKeys(reflect.TypeOf(AIConfig{}), "profiles."+name, &keys)
+	}
+	for _, name := range config.RouteNames() {
+		collectKeys(reflect.TypeOf(Route{}), "routing."+name, &keys)
+	}
+	return keys
+}
+
+// GetValue returns the value stored under a dotted key such as "ai.model"
+// or "profiles.local.endpoint"
+func GetValue(config *Config, key string) (interface{}, error) {
+	field, err := lookupField(config, key, false)
+	if err != nil {
+		return nil, err
+	}
+	return field.Interface(), nil
+}
+
+// SetValue parses value according to the type of the field named by key and
+// stores it, creating profile and routing entries as needed. Use ValidateKey
+// to check the stored value against its rule.
+func SetValue(config *Config, key, value string) error {
+	field, err := lookupField(config, key, true)
+	if err != nil {
+		return err
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: expected true or false, got %q", key, value)
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: expected an integer, got %q", key, value)
+		}
+		field.SetInt(parsed)
+	default:
+		return fmt.Errorf("setting %s is not supported", key)
+	}
+
+	return nil
+}
+
+// UnsetValue restores the field named by key to its default value. Profile
+// and routing settings have no defaults and are cleared.
+func UnsetValue(config *Config, key string) error {
+	field, err := lookupField(config, key, false)
+	if err != nil {
+		return err
+	}
+
+	defaultField, err := lookupField(DefaultConfig(), key, false)
+	if err != nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	field.Set(defaultField)
+	return nil
+}
+
+// IsSecret reports whether key holds a credential that must not be written
+// to configuration files in plaintext
+func IsSecret(key string) bool {
+	return key == secretField || strings.HasSuffix(key, "."+secretField)
+}
+
+// FormatValue renders a configuration value for display, redacting secrets.
+// Credential references such as keystore:ai.api_key are shown as they are.
+func FormatValue(key string, value interface{}) string {
+	if IsSecret(key) && !credentials.IsReference(fmt.Sprint(value)) {
+		return RedactSecret(fmt.Sprint(value))
+	}
+	return fmt.Sprint(value)
+}
+
+// Redacted returns a copy of the configuration with secrets masked
+func Redacted(config *Config) *Config {
+	copied := *config
+	copied.AI = redactedAI(copied.AI)
+
+	if config.Profiles != nil {
+		copied.Profiles = make(map[string]*AIConfig, len(config.Profiles))
+		for name, profile := range config.Profiles {
+			redacted := redactedAI(*profile)
+			copied.Profiles[name] = &redacted
+		}
+	}
+	return &copied
+}
+
+// redactedAI masks the API key of an AI block unless it is a credential reference
+func redactedAI(settings AIConfig) AIConfig {
+	if !credentials.IsReference(settings.APIKey) {
+		settings.APIKey = RedactSecret(settings.APIKey)
+	}
+	return settings
+}
+
+// RedactSecret masks all but the last four characters of a secret
+func RedactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if len(secret) <= 8 {
+		return "****"
+	}
+	return "****" + secret[len(secret)-4:]
+}
+
+// lookupField resolves a dotted key to a settable leaf field. Map sections
+// such as profiles take the entry name as the next part of the key; with
+// create set, missing entries are added.
+func lookupField(config *Config, key string, create bool) (reflect.Value, error) {
+	if key == "" {
+		return reflect.Value{}, fmt.Errorf("empty configuration key")
+	}
+	if key == versionKey {
+		return reflect.Value{}, fmt.Errorf("%s is managed by 'k3ss-ai config migrate'", versionKey)
+	}
+
+	value := reflect.ValueOf(config).Elem()
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		switch value.Kind() {
+		case reflect.Struct:
+			index := fieldIndex(value.Type(), part)
+			if index < 0 {
+				return reflect.Value{}, unknownKeyError(key)
+			}
+			value = value.Field(index)
+		case reflect.Map:
+			section := strings.Join(parts[:i], ".")
+			entry := value.MapIndex(reflect.ValueOf(part))
+			if !entry.IsValid() || entry.IsNil() {
+				if !create {
+					return reflect.Value{}, fmt.Errorf("%s has no entry %q", section, part)
+				}
+				if value.IsNil() {
+					value.Set(reflect.MakeMap(value.Type()))
+				}
+				entry = reflect.New(value.Type().Elem().Elem())
+				value.SetMapIndex(reflect.ValueOf(part), entry)
+			}
+			value = entry.Elem()
+		default:
+			return reflect.Value{}, unknownKeyError(key)
+		}
+	}
+
+	if value.Kind() == reflect.Map {
+		return reflect.Value{}, fmt.Errorf("%s is a section, not a setting (try %s.<name>.<key>)", key, key)
+	}
+	if value.Kind() == reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("%s is a section, not a setting (try %s.<key>)", key, key)
+	}
+	return value, nil
+}
+
+// fieldIndex finds the struct field whose yaml tag matches name
+func fieldIndex(t reflect.Type, name string) int {
+	for i := 0; i < t.NumField(); i++ {
+		if yamlName(t.Field(i)) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// collectKeys walks the configuration struct and records every leaf key
+func collectKeys(t reflect.Type, prefix string, keys *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		name := yamlName(t.Field(i))
+		if name == "" || name == "-" || (prefix == "" && name == versionKey) {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		switch t.Field(i).Type.Kind() {
+		case reflect.Struct:
+			collectKeys(t.Field(i).Type, name, keys)
+		case reflect.Map:
+			// Entries are listed by EntryKeys
+		default:
+			*keys = append(*keys, name)
+		}
+	}
+}
+
+// yamlName returns the yaml key for a struct field
+func yamlName(field reflect.StructField) string {
+	tag := field.Tag.Get("yaml")
+	if tag == "" {
+		return strings.ToLower(field.Name)
+	}
+	return strings.Split(tag, ",")[0]
+}
+
+// entryRule returns the key whose validation rule applies to a profile setting
+func entryRule(key string) string {
+	if strings.HasPrefix(key, "profiles.") {
+		parts := strings.Split(key, ".")
+		return "ai." + parts[len(parts)-1]
+	}
+	return key
+}
+
+// unknownKeyError reports an unknown key along with the valid choices
+func unknownKeyError(key string) error {
+	return fmt.Errorf("unknown configuration key %q (valid keys: %s)", key, strings.Join(Keys(), ", "))
+}
@@ -0,0 +1,314 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Configuration layers, from lowest to highest precedence
+const (
+	LayerDefault = "default"
+	LayerUser    = "user"
+	LayerProject = "project"
+	LayerEnv     = "env"
+	LayerFlag    = "flag"
+)
+
+// EnvPrefix is the prefix for environment variable overrides. A key maps to
+// its upper-cased form with dots replaced by underscores, so ai.model is
+// read from K3SS_AI_AI_MODEL and git.commit_style from K3SS_AI_GIT_COMMIT_STYLE.
+const EnvPrefix = "K3SS_AI_"
+
+// ProjectConfigPath is the location of the project configuration relative to the project root
+var ProjectConfigPath = filepath.Join(".k3ss-ai", "config.yaml")
+
+// Origin records which layer supplied a configuration value
+type Origin struct {
+	Layer    string
+	Location string // file path, environment variable or flag name
+}
+
+func (o Origin) String() string {
+	if o.Location == "" {
+		return o.Layer
+	}
+	return fmt.Sprintf("%s: %s", o.Layer, o.Location)
+}
+
+// Origins maps each dotted key to the layer its effective value came from
+type Origins map[string]Origin
+
+// LoadOptions describes where each configuration layer is read from
+type LoadOptions struct {
+	// UserPath is the user configuration file; empty means ~/.k3ss-ai.yaml
+	UserPath string
+
+	// WorkDir is where the search for the project configuration starts; empty means cwd
+	WorkDir string
+
+	// Environ is the environment in os.Environ form; nil means the process environment
+	Environ []string
+
+	// Flags holds values given explicitly on the command line
+	Flags []FlagOverride
+}
+
+// FlagOverride is a configuration value set by a command-line flag
+type FlagOverride struct {
+	Name  string // flag name, without dashes
+	Key   string // dotted configuration key
+	Value string
+}
+
+// LoadLayered merges defaults, the user file, the project file, environment
+// variables and flags, in that order. It never writes any files. Unknown keys
+// and invalid values in any layer are reported together as a *ValidationError.
+func LoadLayered(opts LoadOptions) (*Config, Origins, error) {
+	config := DefaultConfig()
+	origins := make(Origins)
+	for _, key := range Keys() {
+		origins[key] = Origin{Layer: LayerDefault}
+	}
+	var problems []Problem
+
+	userPath, err := ResolvePath(opts.UserPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	fileProblems, err := applyFile(config, origins, userPath, LayerUser)
+	if err != nil {
+		return nil, nil, err
+	}
+	problems = append(problems, fileProblems...)
+
+	workDir := opts.WorkDir
+	if workDir == "" {
+		if workDir, err = os.Getwd(); err != nil {
+			return nil, nil, fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+	if projectPath := FindProjectConfig(workDir); projectPath != "" {
+		fileProblems, err := applyFile(config, origins, projectPath, LayerProject)
+		if err != nil {
+			return nil, nil, err
+		}
+		problems = append(problems, fileProblems...)
+	}
+
+	environ := opts.Environ
+	if environ == nil {
+		environ = os.Environ()
+	}
+	problems = append(problems, applyEnv(config, origins, environ)...)
+
+	for _, flag := range opts.Flags {
+		origin := Origin{Layer: LayerFlag, Location: "--" + flag.Name}
+		if problem := applyValue(config, origins, flag.Key, flag.Value, origin, 0); problem != nil {
+			problems = append(problems, *problem)
+		}
+	}
+
+	if len(problems) == 0 {
+		problems = checkReferences(config, origins)
+	}
+	if len(problems) > 0 {
+		return nil, nil, &ValidationError{Problems: problems}
+	}
+	return config, origins, nil
+}
+
+// FindProjectConfig walks up from dir looking for .k3ss-ai/config.yaml and
+// returns its path, or "" if there is none
+func FindProjectConfig(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		candidate := filepath.Join(dir, ProjectConfigPath)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// EnvName returns the environment variable that overrides key
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// applyFile overlays the keys present in a YAML file onto config, returning
+// a problem for every unknown key or invalid value
+func applyFile(config *Config, origins Origins, path, layer string) ([]Problem, error) {
+	values, problems, err := readFileValues(path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := values[key]
+		origin := Origin{Layer: layer, Location: path}
+		if problem := applyValue(config, origins, key, value.value, origin, value.line); problem != nil {
+			problems = append(problems, *problem)
+		}
+	}
+
+	return problems, nil
+}
+
+// applyEnv overlays K3SS_AI_* environment variables onto config
+func applyEnv(config *Config, origins Origins, environ []string) []Problem {
+	env := make(map[string]string)
+	for _, entry := range environ {
+		if name, value, ok := strings.Cut(entry, "="); ok && strings.HasPrefix(name, EnvPrefix) {
+			env[name] = value
+		}
+	}
+
+	var problems []Problem
+	for _, key := range Keys() {
+		name := EnvName(key)
+		value, ok := env[name]
+		if !ok {
+			continue
+		}
+		origin := Origin{Layer: LayerEnv, Location: name}
+		if problem := applyValue(config, origins, key, value, origin, 0); problem != nil {
+			problems = append(problems, *problem)
+		}
+	}
+
+	return problems
+}
+
+// applyValue sets and validates a single key, recording its origin on success
+func applyValue(config *Config, origins Origins, key, value string, origin Origin, line int) *Problem {
+	previous, lookupErr := GetValue(config, key)
+
+	err := SetValue(config, key, value)
+	if err == nil {
+		err = ValidateKey(config, key)
+	}
+	if err != nil {
+		// Keep the lower layer's value so later checks see a valid configuration
+		if lookupErr == nil {
+			SetValue(config, key, fmt.Sprint(previous))
+		} else {
+			UnsetValue(config, key)
+		}
+		return &Problem{Source: origin.Location, Line: line, Key: key, Message: err.Error()}
+	}
+
+	origins[key] = origin
+	return nil
+}
+
+// fileValue is a setting read from a configuration file
+type fileValue struct {
+	value string
+	line  int
+}
+
+// readFileValues parses a configuration file strictly, upgrading older schema
+// versions in memory. A missing file yields no values.
+func readFileValues(path string) (map[string]fileValue, []Problem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("failed to parse config file %s: line %d: top level must be a mapping", path, root.Line)
+	}
+
+	version, err := documentVersion(root)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if _, err := migrateDocument(root, version); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	values := make(map[string]fileValue)
+	var problems []Problem
+	var walk func(node *yaml.Node, prefix string, t reflect.Type)
+	walk = func(node *yaml.Node, prefix string, t reflect.Type) {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			name, value := node.Content[i], node.Content[i+1]
+			key := name.Value
+			if prefix != "" {
+				key = prefix + "." + name.Value
+			}
+			if prefix == "" && key == versionKey {
+				// Handled by documentVersion
+				continue
+			}
+
+			fieldType := t
+			if t.Kind() == reflect.Struct {
+				index := fieldIndex(t, name.Value)
+				if index < 0 {
+					problems = append(problems, Problem{Source: path, Line: name.Line, Key: key, Message: "unknown key"})
+					continue
+				}
+				fieldType = t.Field(index).Type
+			} else {
+				// An entry of a map section such as profiles
+				fieldType = t.Elem().Elem()
+				if strings.Contains(name.Value, ".") {
+					problems = append(problems, Problem{Source: path, Line: name.Line, Key: key, Message: "entry names must not contain dots"})
+					continue
+				}
+			}
+
+			switch {
+			case value.Tag == "!!null":
+				// An empty value leaves the lower layer in place
+			case fieldType.Kind() == reflect.Struct || fieldType.Kind() == reflect.Map:
+				if value.Kind != yaml.MappingNode {
+					problems = append(problems, Problem{Source: path, Line: value.Line, Key: key, Message: "must be a section of settings"})
+					continue
+				}
+				walk(value, key, fieldType)
+			case value.Kind != yaml.ScalarNode:
+				problems = append(problems, Problem{Source: path, Line: value.Line, Key: key, Message: "must be a single value"})
+			default:
+				values[key] = fileValue{value: value.Value, line: value.Line}
+			}
+		}
+	}
+	walk(root, "", reflect.TypeOf(Config{}))
+
+	return values, problems, nil
+}
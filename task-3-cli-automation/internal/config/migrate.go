@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the configuration schema version written by this release.
+// Files without a version field are treated as version 0.
+const CurrentVersion = 1
+
+// versionKey is the top-level key holding the schema version
+const versionKey = "version"
+
+// migration upgrades a configuration document by one schema version
+type migration struct {
+	to          int
+	description string
+	apply       func(root *yaml.Node) error
+}
+
+// migrations lists every schema upgrade in order
+var migrations = []migration{
+	{
+		to:          1,
+		description: "add schema version",
+	},
+}
+
+// MigrationStep describes a migration applied to a file
+type MigrationStep struct {
+	To          int
+	Description string
+}
+
+// MigrateFile upgrades the configuration file at path to CurrentVersion,
+// keeping a copy of the original next to it with a .bak suffix
+func MigrateFile(path string) (int, []MigrationStep, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	doc, err := readDocument(path)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	from, err := documentVersion(doc.Content[0])
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	steps, err := migrateDocument(doc.Content[0], from)
+	if err != nil {
+		return from, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(steps) == 0 {
+		return from, nil, nil
+	}
+
+	if err := os.WriteFile(path+".bak", data, 0600); err != nil {
+		return from, nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := writeDocument(path, doc); err != nil {
+		return from, nil, err
+	}
+
+	return from, steps, nil
+}
+
+// FileVersion reports the schema version of the configuration file at path.
+// A missing file reports CurrentVersion since there is nothing to migrate.
+func FileVersion(path string) (int, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return CurrentVersion, nil
+	}
+
+	doc, err := readDocument(path)
+	if err != nil {
+		return 0, err
+	}
+
+	version, err := documentVersion(doc.Content[0])
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+	return version, nil
+}
+
+// migrateDocument applies every migration newer than version to a mapping node
+func migrateDocument(root *yaml.Node, version int) ([]MigrationStep, error) {
+	if version > CurrentVersion {
+		return nil, fmt.Errorf("config version %d is newer than supported version %d; upgrade k3ss-ai", version, CurrentVersion)
+	}
+
+	var steps []MigrationStep
+	for _, m := range migrations {
+		if m.to <= version {
+			continue
+		}
+		if m.apply != nil {
+			if err := m.apply(root); err != nil {
+				return nil, fmt.Errorf("migration to version %d failed: %w", m.to, err)
+			}
+		}
+		setVersion(root, m.to)
+		steps = append(steps, MigrationStep{To: m.to, Description: m.description})
+	}
+	return steps, nil
+}
+
+// documentVersion reads the schema version from a mapping node
+func documentVersion(root *yaml.Node) (int, error) {
+	node := mappingValue(root, versionKey)
+	if node == nil {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(node.Value)
+	if err != nil || node.Kind != yaml.ScalarNode || version < 0 {
+		return 0, fmt.Errorf("line %d: version must be a non-negative integer, got %q", node.Line, node.Value)
+	}
+	return version, nil
+}
+
+// setVersion stores the schema version as the first key of a mapping node
+func setVersion(root *yaml.Node, version int) {
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(version)}
+	if mappingValue(root, versionKey) != nil {
+		setMappingValue(root, versionKey, value)
+		return
+	}
+	root.Content = append([]*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: versionKey},
+		value,
+	}, root.Content...)
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Route overrides the AI profile and model for one command and its subcommands
+type Route struct {
+	// Profile to use instead of the default profile
+	Profile string `yaml:"profile,omitempty" json:"profile,omitempty"`
+
+	// Model to use instead of the profile's model
+	Model string `yaml:"model,omitempty" json:"model,omitempty"`
+}
+
+// Selection explains how the AI settings for a command were chosen
+type Selection struct {
+	Command string
+	Profile string // empty when only the ai block applies
+	Route   string // routing entry that matched, or empty
+	Pinned  bool   // the profile was chosen explicitly, bypassing routing
+}
+
+// AIFor returns the AI settings for a command path such as "review diff".
+// The ai block is overlaid with the selected profile and then with the most
+// specific routing entry ("review diff", then "review"). When pinned is true
+// the profile was chosen explicitly (e.g. --profile) and routing is skipped.
+func (c *Config) AIFor(command string, pinned bool) (AIConfig, Selection, error) {
+	selection := Selection{Command: command, Profile: c.Profile, Pinned: pinned}
+
+	var route *Route
+	if !pinned {
+		selection.Route, route = c.route(command)
+		if route != nil && route.Profile != "" {
+			selection.Profile = route.Profile
+		}
+	}
+
+	settings := c.AI
+	if selection.Profile != "" {
+		profile, ok := c.Profiles[selection.Profile]
+		if !ok {
+			return AIConfig{}, selection, fmt.Errorf("unknown profile %q (available: %s)", selection.Profile, strings.Join(c.ProfileNames(), ", "))
+		}
+		overlay(reflect.ValueOf(&settings).Elem(), reflect.ValueOf(profile).Elem())
+	}
+
+	if route != nil && route.Model != "" {
+		settings.Model = route.Model
+	}
+	return settings, selection, nil
+}
+
+// ProfileNames returns the names of all configured profiles, sorted
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RouteNames returns the command paths that have routing entries, sorted
+func (c *Config) RouteNames() []string {
+	names := make([]string, 0, len(c.Routing))
+	for name := range c.Routing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// route finds the routing entry for command, falling back to its parents
+func (c *Config) route(command string) (string, *Route) {
+	parts := strings.Fields(command)
+	for i := len(parts); i > 0; i-- {
+		name := strings.Join(parts[:i], " ")
+		if route, ok := c.Routing[name]; ok && route != nil {
+			return name, route
+		}
+	}
+	return "", nil
+}
+
+// checkReferences reports profiles named by the profile key or routing
+// entries that are not defined
+func checkReferences(config *Config, origins Origins) []Problem {
+	var problems []Problem
+	if config.Profile != "" && config.Profiles[config.Profile] == nil {
+		problems = append(problems, Problem{
+			Source:  origins["profile"].Location,
+			Key:     "profile",
+			Message: fmt.Sprintf("unknown profile %q", config.Profile),
+		})
+	}
+
+	for _, name := range config.RouteNames() {
+		route := config.Routing[name]
+		if route == nil || route.Profile == "" || config.Profiles[route.Profile] != nil {
+			continue
+		}
+		key := "routing." + name + ".profile"
+		problems = append(problems, Problem{
+			Source:  origins[key].Location,
+			Key:     key,
+			Message: fmt.Sprintf("unknown profile %q", route.Profile),
+		})
+	}
+	return problems
+}
+
+// overlay copies every non-zero field of src onto dst
+func overlay(dst, src reflect.Value) {
+	for i := 0; i < src.NumField(); i++ {
+		if !src.Field(i).IsZero() {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/credentials"
+)
+
+// Problem describes a single issue found in a configuration source
+type Problem struct {
+	Source  string // file path, environment variable or flag
+	Line    int    // line in the file, or 0 when not applicable
+	Key     string
+	Message string
+}
+
+func (p Problem) String() string {
+	location := p.Source
+	if p.Line > 0 {
+		location = fmt.Sprintf("%s:%d", p.Source, p.Line)
+	}
+	if location == "" {
+		return fmt.Sprintf("%s: %s", p.Key, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, p.Key, p.Message)
+}
+
+// ValidationError collects every problem found while loading or validating configuration
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return "invalid configuration: " + e.Problems[0].String()
+	}
+
+	var lines []string
+	for _, problem := range e.Problems {
+		lines = append(lines, "  "+problem.String())
+	}
+	return fmt.Sprintf("invalid configuration (%d problems):\n%s", len(e.Problems), strings.Join(lines, "\n"))
+}
+
+// envPattern matches an environment variable name, optionally ending in *
+var envPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\*?$|^\*$`)
+
+// rule checks the value of a single configuration key
+type rule func(value interface{}) error
+
+// rules holds the validation rule for each key that has one
+var rules = map[string]rule{
+	"ai.endpoint":            httpURL,
+	"ai.api_key":             credentialReference,
+	"ai.model":               nonEmpty,
+	"ai.timeout":             positive,
+	"git.commit_style":       oneOf("conventional", "descriptive", "concise"),
+	"build.command":          nonEmpty,
+	"workflows.keep_runs":    nonNegative,
+	"workflows.keep_days":    nonNegative,
+	"batch.linters":          toolMap,
+	"sandbox.cpu_time":       nonNegative,
+	"sandbox.memory":         nonNegative,
+	"sandbox.open_files":     nonNegative,
+	"sandbox.env_allowlist":  envAllowlist,
+	"settings.output_format": oneOf("text", "json", "yaml", "markdown"),
+}
+
+// Validate checks every key of a configuration against its rule
+func Validate(config *Config) error {
+	var problems []Problem
+	for _, key := range Keys() {
+		if err := ValidateKey(config, key); err != nil {
+			problems = append(problems, Problem{Key: key, Message: err.Error()})
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// ValidateKey checks the current value of key against its rule
+func ValidateKey(config *Config, key string) error {
+	check, ok := rules[entryRule(key)]
+	if !ok {
+		return nil
+	}
+
+	value, err := GetValue(config, key)
+	if err != nil {
+		return err
+	}
+	return check(value)
+}
+
+// httpURL requires an absolute http(s) URL; empty disables the AI service
+func httpURL(value interface{}) error {
+	raw := value.(string)
+	if raw == "" {
+		return nil
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return fmt.Errorf("must be an http:// or https:// URL, got %q", raw)
+	}
+	return nil
+}
+
+// credentialReference checks the syntax of a credential reference; plaintext
+// keys are still accepted from files and the environment for compatibility
+func credentialReference(value interface{}) error {
+	ref := value.(string)
+	if !credentials.IsReference(ref) {
+		return nil
+	}
+	_, err := credentials.New(ref, nil)
+	return err
+}
+
+// nonEmpty requires a non-blank string
+func nonEmpty(value interface{}) error {
+	if strings.TrimSpace(value.(string)) == "" {
+		return fmt.Errorf("must not be empty")
+	}
+	return nil
+}
+
+// positive requires an integer greater than zero
+func positive(value interface{}) error {
+	if value.(int) <= 0 {
+		return fmt.Errorf("must be greater than zero, got %d", value.(int))
+	}
+	return nil
+}
+
+// nonNegative requires an integer of zero or more
+func nonNegative(value interface{}) error {
+	if value.(int) < 0 {
+		return fmt.Errorf("must not be negative, got %d", value.(int))
+	}
+	return nil
+}
+
+// toolMap requires semicolon-separated .ext=command entries
+func toolMap(value interface{}) error {
+	_, err := ParseToolMap(value.(string))
+	return err
+}
+
+// envAllowlist requires comma-separated variable names, each optionally
+// ending in *
+func envAllowlist(value interface{}) error {
+	for _, name := range SplitList(value.(string)) {
+		if !envPattern.MatchString(name) {
+			return fmt.Errorf("invalid environment variable pattern %q", name)
+		}
+	}
+	return nil
+}
+
+// oneOf requires a string from a fixed set of choices
+func oneOf(choices ...string) rule {
+	return func(value interface{}) error {
+		for _, choice := range choices {
+			if value.(string) == choice {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s, got %q", strings.Join(choices, ", "), value.(string))
+	}
+}
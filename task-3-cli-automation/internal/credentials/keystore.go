@@ -0,0 +1,208 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ErrWrongPassphrase is returned when a keystore cannot be decrypted
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted keystore")
+
+const (
+	keystoreVersion = 1
+	keystoreKDF     = "pbkdf2-sha256"
+
+	// defaultIterations follows current OWASP guidance for PBKDF2-HMAC-SHA256
+	defaultIterations = 600000
+
+	saltSize = 16
+	keySize  = 32
+)
+
+// keystoreFile is the on-disk form of a keystore. Data holds the AES-256-GCM
+// encrypted JSON object of named secrets.
+type keystoreFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// Keystore is an unlocked set of named secrets backed by an encrypted file
+type Keystore struct {
+	path       string
+	key        []byte
+	salt       []byte
+	iterations int
+	secrets    map[string]string
+}
+
+// OpenKeystore unlocks the keystore at path with passphrase. A missing file
+// yields an empty keystore that is created on Save.
+func OpenKeystore(path, passphrase string) (*Keystore, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("keystore passphrase must not be empty")
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		salt := make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("failed to generate salt: %w", err)
+		}
+		return &Keystore{
+			path:       path,
+			key:        pbkdf2SHA256([]byte(passphrase), salt, defaultIterations, keySize),
+			salt:       salt,
+			iterations: defaultIterations,
+			secrets:    make(map[string]string),
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore: %w", err)
+	}
+
+	var file keystoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse keystore %s: %w", path, err)
+	}
+	if file.Version != keystoreVersion || file.KDF != keystoreKDF {
+		return nil, fmt.Errorf("keystore %s uses unsupported format %d/%s", path, file.Version, file.KDF)
+	}
+
+	key := pbkdf2SHA256([]byte(passphrase), file.Salt, file.Iterations, keySize)
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock keystore %s: %w", path, ErrWrongPassphrase)
+	}
+
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to parse keystore %s: %w", path, err)
+	}
+
+	return &Keystore{
+		path:       path,
+		key:        key,
+		salt:       file.Salt,
+		iterations: file.Iterations,
+		secrets:    secrets,
+	}, nil
+}
+
+// Get returns the secret stored under name
+func (k *Keystore) Get(name string) (string, bool) {
+	secret, ok := k.secrets[name]
+	return secret, ok
+}
+
+// Set stores a secret under name
+func (k *Keystore) Set(name, secret string) {
+	k.secrets[name] = secret
+}
+
+// Delete removes the secret stored under name
+func (k *Keystore) Delete(name string) {
+	delete(k.secrets, name)
+}
+
+// Names returns the names of all stored secrets, sorted
+func (k *Keystore) Names() []string {
+	names := make([]string, 0, len(k.secrets))
+	for name := range k.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Save encrypts the keystore and writes it with 0600 permissions
+func (k *Keystore) Save() error {
+	plaintext, err := json.Marshal(k.secrets)
+	if err != nil {
+		return fmt.Errorf("failed to encode keystore: %w", err)
+	}
+
+	aead, err := newAEAD(k.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	data, err := json.MarshalIndent(keystoreFile{
+		Version:    keystoreVersion,
+		KDF:        keystoreKDF,
+		Iterations: k.iterations,
+		Salt:       k.salt,
+		Nonce:      nonce,
+		Data:       aead.Seal(nil, nonce, plaintext, nil),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode keystore: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(k.path), 0700); err != nil {
+		return fmt.Errorf("failed to create keystore directory: %w", err)
+	}
+	if err := writePrivate(k.path, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write keystore: %w", err)
+	}
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialise cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// pbkdf2SHA256 derives a key from a passphrase as described in RFC 8018
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	hashLen := prf.Size()
+	blocks := (keyLen + hashLen - 1) / hashLen
+
+	derived := make([]byte, 0, blocks*hashLen)
+	buf := make([]byte, 4)
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf, uint32(block))
+		prf.Write(buf)
+		u := prf.Sum(nil)
+
+		t := make([]byte, len(u))
+		copy(t, u)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		derived = append(derived, t...)
+	}
+	return derived[:keyLen]
+}
@@ -0,0 +1,268 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Reference schemes understood by New. A configuration value such as
+// "env:OPENAI_API_KEY", "file:~/.secrets/k3ss" or "keystore:ai.api_key"
+// names where a secret lives instead of holding the secret itself.
+const (
+	SchemeEnv      = "env"
+	SchemeFile     = "file"
+	SchemeKeystore = "keystore"
+)
+
+// PassphraseEnv supplies the keystore passphrase non-interactively, e.g. in CI
+const PassphraseEnv = "K3SS_AI_KEYSTORE_PASSPHRASE"
+
+// KeystoreEnv overrides the location of the keystore file
+const KeystoreEnv = "K3SS_AI_KEYSTORE"
+
+// ErrReadOnly is returned when storing a secret through a provider that cannot hold one
+var ErrReadOnly = errors.New("credential provider is read-only")
+
+// Provider resolves and stores a single secret
+type Provider interface {
+	// Get returns the secret
+	Get() (string, error)
+
+	// Set stores the secret
+	Set(secret string) error
+
+	// Reference returns the configuration value that points at this secret
+	Reference() string
+}
+
+// PassphraseFunc obtains the keystore passphrase; confirm asks for it twice
+// when a new keystore is being created
+type PassphraseFunc func(confirm bool) (string, error)
+
+// IsReference reports whether value names a credential provider rather than
+// holding a plaintext secret
+func IsReference(value string) bool {
+	scheme, _, ok := strings.Cut(value, ":")
+	if !ok {
+		return false
+	}
+	switch scheme {
+	case SchemeEnv, SchemeFile, SchemeKeystore:
+		return true
+	}
+	return false
+}
+
+// New returns the provider named by a reference
+func New(ref string, passphrase PassphraseFunc) (Provider, error) {
+	scheme, target, ok := strings.Cut(ref, ":")
+	if !ok || !IsReference(ref) {
+		return nil, fmt.Errorf("%q is not a credential reference (use env:NAME, file:PATH or keystore:NAME)", ref)
+	}
+	if target == "" {
+		return nil, fmt.Errorf("credential reference %q does not name a %s secret", ref, scheme)
+	}
+
+	switch scheme {
+	case SchemeEnv:
+		return EnvProvider{Name: target}, nil
+	case SchemeFile:
+		path, err := expandHome(target)
+		if err != nil {
+			return nil, err
+		}
+		return FileProvider{Path: path}, nil
+	default:
+		path, err := DefaultKeystorePath()
+		if err != nil {
+			return nil, err
+		}
+		return &KeystoreProvider{Path: path, Name: target, Passphrase: passphrase}, nil
+	}
+}
+
+// Resolve returns the secret for a configuration value. Plaintext values are
+// returned unchanged so older configuration files keep working.
+func Resolve(value string, passphrase PassphraseFunc) (string, error) {
+	if value == "" || !IsReference(value) {
+		return value, nil
+	}
+
+	provider, err := New(value, passphrase)
+	if err != nil {
+		return "", err
+	}
+	return provider.Get()
+}
+
+// EnvProvider reads a secret from an environment variable
+type EnvProvider struct {
+	Name string
+}
+
+// Get returns the value of the environment variable
+func (p EnvProvider) Get() (string, error) {
+	value, ok := os.LookupEnv(p.Name)
+	if !ok || value == "" {
+		return "", fmt.Errorf("environment variable %s is not set", p.Name)
+	}
+	return value, nil
+}
+
+// Set always fails; environment variables are managed outside k3ss-ai
+func (p EnvProvider) Set(secret string) error {
+	return fmt.Errorf("cannot store a secret in environment variable %s: %w", p.Name, ErrReadOnly)
+}
+
+// Reference returns "env:NAME"
+func (p EnvProvider) Reference() string {
+	return SchemeEnv + ":" + p.Name
+}
+
+// FileProvider reads a secret from a file that only its owner may access
+type FileProvider struct {
+	Path string
+}
+
+// Get returns the trimmed file contents, refusing files readable by group or others
+func (p FileProvider) Get() (string, error) {
+	info, err := os.Stat(p.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read credential file: %w", err)
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		return "", fmt.Errorf("credential file %s has permissions %04o; run 'chmod 600 %s'", p.Path, perm, p.Path)
+	}
+
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read credential file: %w", err)
+	}
+
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("credential file %s is empty", p.Path)
+	}
+	return secret, nil
+}
+
+// Set writes the secret with 0600 permissions
+func (p FileProvider) Set(secret string) error {
+	if err := os.MkdirAll(filepath.Dir(p.Path), 0700); err != nil {
+		return fmt.Errorf("failed to create credential directory: %w", err)
+	}
+	if err := writePrivate(p.Path, []byte(secret+"\n")); err != nil {
+		return fmt.Errorf("failed to write credential file: %w", err)
+	}
+	return nil
+}
+
+// Reference returns "file:PATH"
+func (p FileProvider) Reference() string {
+	return SchemeFile + ":" + p.Path
+}
+
+// KeystoreProvider reads a named secret from the encrypted keystore
+type KeystoreProvider struct {
+	Path       string
+	Name       string
+	Passphrase PassphraseFunc
+}
+
+// Get unlocks the keystore and returns the named entry
+func (p *KeystoreProvider) Get() (string, error) {
+	if _, err := os.Stat(p.Path); os.IsNotExist(err) {
+		return "", fmt.Errorf("keystore %s does not exist; run 'k3ss-ai config set %s <secret>'", p.Path, p.Name)
+	}
+
+	store, err := p.open(false)
+	if err != nil {
+		return "", err
+	}
+
+	secret, ok := store.Get(p.Name)
+	if !ok {
+		return "", fmt.Errorf("keystore %s has no entry %q", p.Path, p.Name)
+	}
+	return secret, nil
+}
+
+// Set unlocks or creates the keystore and stores the named entry
+func (p *KeystoreProvider) Set(secret string) error {
+	_, statErr := os.Stat(p.Path)
+	store, err := p.open(os.IsNotExist(statErr))
+	if err != nil {
+		return err
+	}
+
+	store.Set(p.Name, secret)
+	return store.Save()
+}
+
+// Reference returns "keystore:NAME"
+func (p *KeystoreProvider) Reference() string {
+	return SchemeKeystore + ":" + p.Name
+}
+
+func (p *KeystoreProvider) open(create bool) (*Keystore, error) {
+	if p.Passphrase == nil {
+		return nil, fmt.Errorf("a passphrase is required to unlock keystore %s (set %s)", p.Path, PassphraseEnv)
+	}
+
+	passphrase, err := p.Passphrase(create)
+	if err != nil {
+		return nil, err
+	}
+	return OpenKeystore(p.Path, passphrase)
+}
+
+// DefaultKeystorePath returns $K3SS_AI_KEYSTORE, or ~/.k3ss-ai/keystore.json
+func DefaultKeystorePath() (string, error) {
+	if path := os.Getenv(KeystoreEnv); path != "" {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".k3ss-ai", "keystore.json"), nil
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// writePrivate atomically replaces path with data readable only by its owner
+func writePrivate(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -1,14 +1,19 @@
 package git
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/ai"
 )
 
 // CommitMessageGenerator generates AI-powered commit messages
 type CommitMessageGenerator struct {
 	gitService *GitService
+	aiClient   *ai.Client
 }
 
 // NewCommitMessageGenerator creates a new commit message generator
@@ -16,6 +21,11 @@ func NewCommitMessageGenerator(gitService *GitService) *CommitMessageGenerator {
 	return &CommitMessageGenerator{gitService: gitService}
 }
 
+// SetAIClient enables AI generation; without a client the diff heuristics are used
+func (c *CommitMessageGenerator) SetAIClient(client *ai.Client) {
+	c.aiClient = client
+}
+
 // GenerateCommitMessage generates a commit message based on staged changes
 func (c *CommitMessageGenerator) GenerateCommitMessage(style string) (string, error) {
 	// Get the diff of staged changes
@@ -28,10 +38,35 @@ func (c *CommitMessageGenerator) GenerateCommitMessage(style string) (string, er
 		return "", fmt.Errorf("no staged changes found")
 	}
 	
+	if c.aiClient != nil {
+		message, err := c.generateWithAI(diff, style)
+		if err == nil {
+			return message, nil
+		}
+		fmt.Fprintf(os.Stderr, "Warning: AI commit message generation failed, falling back to diff analysis: %v\n", err)
+	}
+	
 	// Analyze the diff and generate message
 	return c.analyzeAndGenerateMessage(diff, style)
 }
 
+// generateWithAI asks the AI service for a commit message in the given style
+func (c *CommitMessageGenerator) generateWithAI(diff, style string) (string, error) {
+	prompt := fmt.Sprintf("Write a %s git commit message for the following staged changes. "+
+		"Reply with the commit message only.\n\n%s", style, diff)
+	
+	response, err := c.aiClient.Ask(context.Background(), ai.RequestGenerate, prompt)
+	if err != nil {
+		return "", err
+	}
+	
+	message := strings.TrimSpace(strings.Trim(strings.TrimSpace(response.Content), "`"))
+	if message == "" {
+		return "", fmt.Errorf("AI service returned an empty commit message")
+	}
+	return message, nil
+}
+
 // analyzeAndGenerateMessage analyzes the diff and generates appropriate commit message
 func (c *CommitMessageGenerator) analyzeAndGenerateMessage(diff, style string) (string, error) {
 	analysis := c.analyzeDiff(diff)
@@ -0,0 +1,183 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// hookMarker identifies hook scripts written by InstallHook
+const hookMarker = "# Installed by k3ss-ai hooks install"
+
+// chainedSuffix is appended to a user's hook when a shim takes its place;
+// the shim runs it first and stops if it fails
+const chainedSuffix = ".k3ss-ai-chained"
+
+// HookStatus describes the state of one git hook
+type HookStatus struct {
+	Name      string
+	Path      string
+	Exists    bool   // a hook file is present
+	Installed bool   // the hook file is a k3ss-ai shim
+	Current   bool   // the shim matches what InstallHook would write now
+	Chained   string // the user hook the shim runs first, if any
+}
+
+// HooksDir returns the directory git runs hooks from, honouring core.hooksPath
+func (g *GitService) HooksDir() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--git-path", "hooks")
+	cmd.Dir = g.repoPath
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find hooks directory: %w", err)
+	}
+
+	dir := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(g.repoPath, dir)
+	}
+	return dir, nil
+}
+
+// InstallHook writes a shim for the named hook that runs
+// "<executable> workflow trigger --event <name>". An existing hook that is not
+// a shim is kept next to it and run first, so user hooks are chained.
+func (g *GitService) InstallHook(name, executable string) error {
+	dir, err := g.HooksDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	path := filepath.Join(dir, name)
+	existing, err := os.ReadFile(path)
+	if err == nil && !isShim(existing) {
+		chained := path + chainedSuffix
+		if _, err := os.Stat(chained); err == nil {
+			return fmt.Errorf("cannot install %s hook: both %s and %s exist", name, path, chained)
+		}
+		if err := os.Rename(path, chained); err != nil {
+			return fmt.Errorf("failed to keep existing %s hook: %w", name, err)
+		}
+	} else if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s hook: %w", name, err)
+	}
+
+	if err := os.WriteFile(path, hookScript(name, executable), 0755); err != nil {
+		return fmt.Errorf("failed to write %s hook: %w", name, err)
+	}
+	return os.Chmod(path, 0755)
+}
+
+// UninstallHook removes the shim for the named hook and restores the hook it
+// chained, if any. A hook that is not a shim is left alone.
+func (g *GitService) UninstallHook(name string) error {
+	status, err := g.HookStatus(name, "")
+	if err != nil {
+		return err
+	}
+	if !status.Installed {
+		return fmt.Errorf("%s hook is not installed by k3ss-ai", name)
+	}
+
+	if err := os.Remove(status.Path); err != nil {
+		return fmt.Errorf("failed to remove %s hook: %w", name, err)
+	}
+	if status.Chained != "" {
+		if err := os.Rename(status.Chained, status.Path); err != nil {
+			return fmt.Errorf("failed to restore %s hook: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// HookStatus reports the state of the named hook. Current compares the shim
+// with one for executable; pass "" when that does not matter.
+func (g *GitService) HookStatus(name, executable string) (HookStatus, error) {
+	dir, err := g.HooksDir()
+	if err != nil {
+		return HookStatus{}, err
+	}
+
+	status := HookStatus{Name: name, Path: filepath.Join(dir, name)}
+	content, err := os.ReadFile(status.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return status, nil
+		}
+		return status, fmt.Errorf("failed to read %s hook: %w", name, err)
+	}
+
+	status.Exists = true
+	status.Installed = isShim(content)
+	status.Current = status.Installed && executable != "" && bytes.Equal(content, hookScript(name, executable))
+	if _, err := os.Stat(status.Path + chainedSuffix); err == nil {
+		status.Chained = status.Path + chainedSuffix
+	}
+	return status, nil
+}
+
+// InstalledHooks returns the names of hooks that are k3ss-ai shims
+func (g *GitService) InstalledHooks() ([]string, error) {
+	dir, err := g.HooksDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read hooks directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), chainedSuffix) {
+			continue
+		}
+		if content, err := os.ReadFile(filepath.Join(dir, entry.Name())); err == nil && isShim(content) {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// isShim reports whether a hook script was written by InstallHook
+func isShim(content []byte) bool {
+	return bytes.Contains(content, []byte(hookMarker))
+}
+
+// hookScript returns the shim for a hook. Hook input on stdin, such as the
+// refs given to pre-push, is saved so both the chained hook and k3ss-ai see it.
+func hookScript(name, executable string) []byte {
+	return []byte(fmt.Sprintf(`#!/bin/sh
+%s; do not edit.
+# Runs the git_hook workflows bound to %s after any chained hook.
+
+k3ss_ai=%s
+command -v "$k3ss_ai" >/dev/null 2>&1 || k3ss_ai=k3ss-ai
+
+input=$(mktemp) || exit 1
+trap 'rm -f "$input"' EXIT
+[ -t 0 ] || cat > "$input"
+
+chained="$0%s"
+if [ -x "$chained" ]; then
+	"$chained" "$@" < "$input" || exit $?
+fi
+
+"$k3ss_ai" workflow trigger --event %s -- "$@" < "$input"
+`, hookMarker, name, shellQuote(executable), chainedSuffix, name))
+}
+
+// shellQuote quotes a value for a POSIX shell
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
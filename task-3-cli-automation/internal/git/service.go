@@ -39,6 +39,31 @@ func (g *GitService) GetDiff(diffRange string) (string, error) {
 	return string(output), nil
 }
 
+// ChangedFiles returns the files changed between ref and the working tree,
+// relative to the repository path, as listed by git diff --name-only. Only
+// files within the repository path are listed; deleted files are left out
+// and untracked files are not part of the diff.
+func (g *GitService) ChangedFiles(ref string) ([]string, error) {
+	if ref == "" || strings.HasPrefix(ref, "-") {
+		return nil, fmt.Errorf("invalid git ref %q", ref)
+	}
+	
+	cmd := exec.Command("git", "diff", "--name-only", "-z", "--relative", "--diff-filter=d", ref, "--")
+	cmd.Dir = g.repoPath
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git diff: %w", err)
+	}
+	
+	var files []string
+	for _, file := range strings.Split(string(output), "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
 // GetStatus returns the current git status
 func (g *GitService) GetStatus() (string, error) {
 	cmd := exec.Command("git", "status", "--porcelain")
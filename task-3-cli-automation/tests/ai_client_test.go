@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/ai"
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/config"
+)
+
+func newTestAIClient(url string, timeout int) *ai.Client {
+	client := ai.NewClient(config.AIConfig{
+		Endpoint: url,
+		APIKey:   "test-key",
+		Model:    "test-model",
+		Timeout:  timeout,
+	})
+	client.SetRetryPolicy(2, time.Millisecond)
+	return client
+}
+
+func TestAIClientSend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ai/request" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("unexpected Authorization header %q", got)
+		}
+
+		var req ai.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if req.Model != "test-model" || req.Type != ai.RequestChat {
+			t.Errorf("unexpected request %+v", req)
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data":    map[string]interface{}{"id": req.ID, "content": "echo: " + req.Content},
+		})
+	}))
+	defer server.Close()
+
+	response, err := newTestAIClient(server.URL, 5).Ask(context.Background(), ai.RequestChat, "hello")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.Content != "echo: hello" {
+		t.Errorf("unexpected content %q", response.Content)
+	}
+}
+
+func TestAIClientRetriesTransientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data":    map[string]interface{}{"content": "ok"},
+		})
+	}))
+	defer server.Close()
+
+	if _, err := newTestAIClient(server.URL, 5).Ask(context.Background(), ai.RequestChat, "hi"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestAIClientTypedErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   map[string]interface{}{"code": "BAD_REQUEST", "message": "missing content"},
+		})
+	}))
+	defer server.Close()
+
+	_, err := newTestAIClient(server.URL, 5).Ask(context.Background(), ai.RequestChat, "")
+
+	var apiErr *ai.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *ai.APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != "BAD_REQUEST" {
+		t.Errorf("unexpected error %+v", apiErr)
+	}
+	if calls != 1 {
+		t.Errorf("client errors must not be retried, got %d attempts", calls)
+	}
+
+	_, err = ai.NewClient(config.AIConfig{}).Ask(context.Background(), ai.RequestChat, "hi")
+	if !errors.Is(err, ai.ErrNoEndpoint) {
+		t.Errorf("expected ErrNoEndpoint, got %v", err)
+	}
+}
+
+func TestAIClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	_, err := newTestAIClient(server.URL, 1).Ask(context.Background(), ai.RequestChat, "hi")
+
+	var timeoutErr *ai.TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected *ai.TimeoutError, got %v", err)
+	}
+}
+
+func TestAIClientStream(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"server-sent events", "text/event-stream", "data: {\"content\":\"Hel\"}\n\ndata: {\"content\":\"lo\"}\n\ndata: [DONE]\n\n"},
+		{"chunked JSON", "application/x-ndjson", "{\"content\":\"Hel\"}\n{\"content\":\"lo\",\"done\":true}\n"},
+		{"plain envelope", "application/json", `{"success":true,"data":{"content":"Hello"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			var tokens []string
+			response, err := newTestAIClient(server.URL, 5).Stream(context.Background(), ai.NewRequest(ai.RequestChat, "hi"), func(token string) error {
+				tokens = append(tokens, token)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if response.Content != "Hello" {
+				t.Errorf("unexpected content %q (tokens %q)", response.Content, tokens)
+			}
+		})
+	}
+}
+
+func TestAIClientStreamCancellation(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"content\":\"partial\"}\n\n"))
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := newTestAIClient(server.URL, 5).Stream(ctx, ai.NewRequest(ai.RequestChat, "hi"), func(token string) error {
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
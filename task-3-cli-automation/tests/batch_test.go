@@ -0,0 +1,253 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/automation"
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/config"
+)
+
+func TestBatchFormatRunsFormatter(t *testing.T) {
+	if _, err := exec.LookPath("gofmt"); err != nil {
+		t.Skip("gofmt is not installed")
+	}
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "main.go"), "package main\nfunc  main( ) {\n}\n")
+
+	result, err := automation.NewBatchProcessor(root).ExecuteBatchOperation(&automation.BatchOperation{
+		Operation: "format",
+		Pattern:   "*.go",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Success || result.FilesProcessed != 1 {
+		t.Fatalf("expected the file to be formatted, got %+v", result)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "main.go")); string(data) != "package main\n\nfunc main() {\n}\n" {
+		t.Errorf("expected gofmt to rewrite the file, got %q", data)
+	}
+}
+
+func TestBatchLintFixReportsToolFailures(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "app.py"), "x=1\n")
+	writeFile(t, filepath.Join(root, "app.rb"), "x=1\n")
+	writeFile(t, filepath.Join(root, "lint.sh"), "#!/bin/sh\necho \"style problem in $1\"\nexit 3\n")
+	if err := os.Chmod(filepath.Join(root, "lint.sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	processor := automation.NewBatchProcessor(root)
+	processor.SetLinters(map[string]string{".py": "./lint.sh {file}", ".rb": "no-such-linter-k3ss --fix"})
+	result, err := processor.ExecuteBatchOperation(&automation.BatchOperation{
+		Operation: "lint-fix",
+		Pattern:   "app.*",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Success || len(result.Errors) != 2 {
+		t.Fatalf("expected both files to fail, got %+v", result)
+	}
+
+	errs := make(map[string]automation.BatchError)
+	for _, batchErr := range result.Errors {
+		errs[batchErr.File] = batchErr
+	}
+	if failed := errs["app.py"]; failed.MissingTool != "" || failed.Output != "style problem in app.py\n" {
+		t.Errorf("expected the linter output to be captured, got %+v", failed)
+	}
+	if missing := errs["app.rb"]; missing.MissingTool != "no-such-linter-k3ss" || missing.Output != "" {
+		t.Errorf("expected the missing linter to be reported, got %+v", missing)
+	}
+}
+
+func TestParseToolMap(t *testing.T) {
+	tools, err := config.ParseToolMap(".go=golangci-lint run --fix {dir}; .py = ruff check --fix {file};")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tools) != 2 || tools[".go"] != "golangci-lint run --fix {dir}" || tools[".py"] != "ruff check --fix {file}" {
+		t.Errorf("unexpected tools %v", tools)
+	}
+
+	if _, err := config.ParseToolMap("go=staticcheck"); err == nil || !strings.Contains(err.Error(), "expected .ext=command") {
+		t.Errorf("expected an error for an extension without a dot, got %v", err)
+	}
+}
+
+// writeLintScript writes a linter that sleeps or fails according to the file
+// name, configured for .t files
+func writeLintScript(t *testing.T, root, script string) *automation.BatchProcessor {
+	t.Helper()
+	writeFile(t, filepath.Join(root, "lint.sh"), "#!/bin/sh\n"+script)
+	if err := os.Chmod(filepath.Join(root, "lint.sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.t", "b.t", "c.t", "d.t", "e.t"} {
+		writeFile(t, filepath.Join(root, name), "")
+	}
+
+	processor := automation.NewBatchProcessor(root)
+	processor.SetOutput(nil)
+	processor.SetLinters(map[string]string{".t": "./lint.sh {file}"})
+	return processor
+}
+
+func TestBatchWorkersKeepFileOrder(t *testing.T) {
+	root := t.TempDir()
+	processor := writeLintScript(t, root, `case "$1" in
+a.t) sleep 0.3 ;;
+c.t) echo "bad"; exit 1 ;;
+esac
+`)
+
+	var updates []automation.BatchProgress
+	result, err := processor.ExecuteBatchOperation(&automation.BatchOperation{
+		Operation: "lint-fix",
+		Pattern:   "*.t",
+		Jobs:      3,
+		Progress:  func(progress automation.BatchProgress) { updates = append(updates, progress) },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var order []string
+	for _, file := range result.Files {
+		order = append(order, file.File)
+	}
+	if strings.Join(order, ",") != "a.t,b.t,c.t,d.t,e.t" {
+		t.Errorf("expected results in file order, got %v", order)
+	}
+	if result.Files[0].Duration < 300*time.Millisecond || !result.Files[0].Success {
+		t.Errorf("expected the duration of the slow file, got %+v", result.Files[0])
+	}
+	if result.Success || result.FilesProcessed != 4 || len(result.Errors) != 1 || result.Errors[0].File != "c.t" {
+		t.Errorf("expected only c.t to fail, got %+v", result)
+	}
+
+	if len(updates) != 5 {
+		t.Fatalf("expected progress after every file, got %d updates", len(updates))
+	}
+	if last := updates[4]; last.Done != 5 || last.Total != 5 || last.Errors != 1 || last.ETA() != 0 {
+		t.Errorf("unexpected final progress %+v", last)
+	}
+}
+
+func TestBatchFailFastCancelsRemainingFiles(t *testing.T) {
+	root := t.TempDir()
+	processor := writeLintScript(t, root, `case "$1" in
+a.t) sleep 0.1; exit 1 ;;
+b.t) sleep 30 ;;
+esac
+`)
+
+	start := time.Now()
+	result, err := processor.ExecuteBatchOperation(&automation.BatchOperation{
+		Operation: "lint-fix",
+		Pattern:   "*.t",
+		Jobs:      2,
+		FailFast:  true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the running linter to be interrupted, took %v", elapsed)
+	}
+
+	if len(result.Errors) != 1 || result.Errors[0].File != "a.t" {
+		t.Errorf("expected only the first failure to be reported, got %+v", result.Errors)
+	}
+	if result.FilesCancelled != 4 || !result.Files[1].Cancelled || result.Success {
+		t.Errorf("expected the other files to be cancelled, got %+v", result)
+	}
+}
+
+// selectedFiles returns the files a dry run of the operation selects
+func selectedFiles(t *testing.T, root string, operation *automation.BatchOperation) string {
+	t.Helper()
+	operation.Operation = "format"
+	operation.DryRun = true
+	result, err := automation.NewBatchProcessor(root).ExecuteBatchOperation(operation)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var files []string
+	for _, file := range result.Files {
+		files = append(files, filepath.ToSlash(file.File))
+	}
+	return strings.Join(files, ",")
+}
+
+func TestBatchFileSelection(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{
+		"main.go", "contest.go", "build.log", "test/helper.go", "vendor/lib.go",
+		"gen/out.go", "src/c.js", "src/a/b.js", "node_modules/m/index.js",
+	} {
+		writeFile(t, filepath.Join(root, name), "")
+	}
+	writeFile(t, filepath.Join(root, ".gitignore"), "*.log\n")
+	writeFile(t, filepath.Join(root, ".k3ss-aiignore"), "gen/\n")
+
+	tests := []struct {
+		name      string
+		operation automation.BatchOperation
+		expected  string
+	}{
+		{"excluding a directory keeps similar names", automation.BatchOperation{Pattern: "*.go", Recursive: true, Exclude: []string{"test"}},
+			"contest.go,main.go,vendor/lib.go"},
+		{"top level only without -r", automation.BatchOperation{Patterns: []string{"*.go"}},
+			"contest.go,main.go"},
+		{"doublestar with a negation", automation.BatchOperation{Patterns: []string{"**/*.go", "!vendor/**"}},
+			"contest.go,main.go,test/helper.go"},
+		{"multiple patterns", automation.BatchOperation{Patterns: []string{"src/**/*.js", "main.*"}, Exclude: []string{"node_modules"}},
+			"main.go,src/a/b.js,src/c.js"},
+		{"leading negation starts from every file", automation.BatchOperation{Patterns: []string{"!*.go", "!.*"}, Recursive: true, Exclude: []string{"node_modules"}},
+			"src/a/b.js,src/c.js"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectedFiles(t, root, &tt.operation); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBatchChangedSince(t *testing.T) {
+	root := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", root, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	git("init", "-q")
+	for _, name := range []string{"a.go", "b.go", "c.go", "docs/old name.md"} {
+		writeFile(t, filepath.Join(root, name), "package a\n")
+	}
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	writeFile(t, filepath.Join(root, "b.go"), "package b\n++ c.go\n") // an added line reading "+++ c.go"
+	writeFile(t, filepath.Join(root, "untracked.go"), "package u\n")
+	git("rm", "-q", "a.go")
+	git("mv", "docs/old name.md", "docs/new name.md")
+
+	if got := selectedFiles(t, root, &automation.BatchOperation{Pattern: "**", ChangedSince: "HEAD"}); got != "b.go,docs/new name.md" {
+		t.Errorf("expected the modified and renamed files, got %s", got)
+	}
+	if got := selectedFiles(t, root, &automation.BatchOperation{Pattern: "*.go", ChangedSince: "HEAD"}); got != "b.go" {
+		t.Errorf("expected patterns to narrow the changed files, got %s", got)
+	}
+}
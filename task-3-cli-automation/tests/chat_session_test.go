@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/ai"
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/chat"
+)
+
+func TestChatREPLPersistsSession(t *testing.T) {
+	dir := t.TempDir()
+	store := chat.NewSessionStore(dir)
+	session := chat.NewSession("test-model")
+
+	var requests []*ai.Request
+	send := func(ctx context.Context, request *ai.Request) (*ai.Response, error) {
+		requests = append(requests, request)
+		return &ai.Response{Content: "answer"}, nil
+	}
+
+	input := strings.Join([]string{
+		"first line \\",
+		"second line",
+		"/model other-model",
+		`"""`,
+		"block one",
+		"block two",
+		`"""`,
+		"/exit",
+	}, "\n")
+
+	var out bytes.Buffer
+	repl := chat.NewREPL(strings.NewReader(input), &out, send, store, session, nil)
+	if err := repl.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(requests))
+	}
+	if requests[0].Content != "first line \nsecond line" {
+		t.Errorf("unexpected continuation input %q", requests[0].Content)
+	}
+	if requests[1].Model != "other-model" {
+		t.Errorf("expected /model to switch model, got %q", requests[1].Model)
+	}
+	if !strings.Contains(requests[1].Content, "block one\nblock two") || !strings.Contains(requests[1].Content, "Conversation so far") {
+		t.Errorf("expected history and block input in prompt, got %q", requests[1].Content)
+	}
+
+	resumed, err := store.Load(session.ID)
+	if err != nil {
+		t.Fatalf("failed to load saved session: %v", err)
+	}
+	if len(resumed.Messages) != 4 {
+		t.Errorf("expected 4 stored messages, got %d", len(resumed.Messages))
+	}
+}
+
+func TestChatSessionIDsAreUnique(t *testing.T) {
+	first, second := chat.NewSession("test-model"), chat.NewSession("test-model")
+	if first.ID == second.ID {
+		t.Errorf("expected sessions started together to get different IDs, both got %s", first.ID)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"testing"
 	
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -60,8 +61,47 @@ func TestCLICommands(t *testing.T) {
 }
 
 func TestConfigManagement(t *testing.T) {
-	// Test configuration loading and saving
-	t.Log("Configuration management tests not yet implemented")
+	cfg := config.DefaultConfig()
+	
+	if err := config.SetValue(cfg, "ai.model", "claude-x"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := config.SetValue(cfg, "ai.timeout", "60"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := config.SetValue(cfg, "git.auto_commit", "true"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.AI.Model != "claude-x" || cfg.AI.Timeout != 60 || !cfg.Git.AutoCommit {
+		t.Errorf("dotted-path updates not applied: %+v", cfg)
+	}
+	
+	// Invalid keys and values are rejected
+	if err := config.SetValue(cfg, "ai.modle", "x"); err == nil {
+		t.Error("expected error for unknown key")
+	}
+	if err := config.SetValue(cfg, "ai.timeout", "soon"); err == nil {
+		t.Error("expected error for non-integer timeout")
+	}
+	if err := config.SetValue(cfg, "ai", "x"); err == nil {
+		t.Error("expected error when setting a section")
+	}
+	
+	if err := config.UnsetValue(cfg, "ai.model"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value, _ := config.GetValue(cfg, "ai.model"); value != config.DefaultConfig().AI.Model {
+		t.Errorf("unset should restore the default model, got %v", value)
+	}
+	
+	// Secrets are redacted for display
+	cfg.AI.APIKey = "sk-secret-value-1234"
+	if redacted := config.Redacted(cfg).AI.APIKey; redacted != "****1234" {
+		t.Errorf("unexpected redacted key %q", redacted)
+	}
+	if cfg.AI.APIKey != "sk-secret-value-1234" {
+		t.Error("redaction must not modify the original configuration")
+	}
 }
 
 func TestGitIntegration(t *testing.T) {
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/ai"
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/automation"
+)
+
+// newAIBatchProcessor returns a batch processor for root whose AI service
+// replies with reply, recording the prompts it is sent
+func newAIBatchProcessor(t *testing.T, root, reply string, prompts *[]string) *automation.BatchProcessor {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ai.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if prompts != nil {
+			*prompts = append(*prompts, req.Content)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data":    map[string]interface{}{"id": req.ID, "content": reply},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	processor := automation.NewBatchProcessor(root)
+	processor.SetOutput(nil)
+	processor.SetAIClient(newTestAIClient(server.URL, 5))
+	return processor
+}
+
+func runAddComments(t *testing.T, processor *automation.BatchProcessor, pattern string, apply bool) {
+	t.Helper()
+	result, err := processor.RunBatchOperation(context.Background(), &automation.BatchOperation{
+		Operation: "add-comments",
+		Pattern:   pattern,
+		Apply:     apply,
+		Jobs:      1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("expected add-comments to succeed, got %+v", result.Errors)
+	}
+}
+
+func TestBatchAddCommentsWritesGoPatch(t *testing.T) {
+	root := t.TempDir()
+	source := `package shapes
+
+import "math"
+
+// Circle is documented already
+type Circle struct{ R float64 }
+
+func (c Circle) Area() float64 { return math.Pi * c.R * c.R }
+
+const (
+	Small = 1
+	Large = 2 // trailing comments count
+)
+
+func helper() {}
+
+func Scale(c Circle, by float64) Circle {
+	return Circle{c.R * by}
+}`
+	writeFile(t, filepath.Join(root, "shapes.go"), source)
+
+	var prompts []string
+	reply := "```json\n" + `{"Circle.Area": "Area returns the area of c.", "Small": "Small is a small size.",` +
+		` "Scale": "// Scale returns c scaled\n// by the given factor.", "helper": "not exported"}` + "\n```"
+	processor := newAIBatchProcessor(t, root, reply, &prompts)
+	runAddComments(t, processor, "*.go", false)
+
+	if len(prompts) != 1 || !strings.Contains(prompts[0], "Circle.Area") || strings.Contains(prompts[0], "helper") ||
+		strings.Contains(prompts[0], "- Circle ") || strings.Contains(prompts[0], "Large") {
+		t.Errorf("expected only undocumented exported symbols in the prompt, got %q", prompts)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "shapes.go")); string(data) != source {
+		t.Fatal("expected the file to be left alone until the patch is applied")
+	}
+	patch, err := os.ReadFile(filepath.Join(root, automation.PatchDir, "shapes.go.patch"))
+	if err != nil {
+		t.Fatalf("expected a patch: %v", err)
+	}
+	if pending, _ := processor.PendingPatches(); len(pending) != 1 || pending[0] != "shapes.go" {
+		t.Errorf("expected shapes.go to be pending, got %v", pending)
+	}
+
+	expected := `package shapes
+
+import "math"
+
+// Circle is documented already
+type Circle struct{ R float64 }
+
+// Area returns the area of c.
+func (c Circle) Area() float64 { return math.Pi * c.R * c.R }
+
+const (
+	// Small is a small size.
+	Small = 1
+	Large = 2 // trailing comments count
+)
+
+func helper() {}
+
+// Scale returns c scaled
+// by the given factor.
+func Scale(c Circle, by float64) Circle {
+	return Circle{c.R * by}
+}`
+
+	// The patch is an ordinary unified diff
+	if _, err := exec.LookPath("git"); err == nil {
+		check := t.TempDir()
+		writeFile(t, filepath.Join(check, "shapes.go"), source)
+		cmd := exec.Command("git", "apply", "-")
+		cmd.Dir = check
+		cmd.Stdin = strings.NewReader(string(patch))
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git apply rejected the patch: %v\n%s\n%s", err, output, patch)
+		}
+		if data, _ := os.ReadFile(filepath.Join(check, "shapes.go")); string(data) != expected {
+			t.Errorf("unexpected result of git apply:\n%s", data)
+		}
+	}
+
+	// Applying accepts the reviewed patch without asking again
+	runAddComments(t, processor, "*.go", true)
+	if len(prompts) != 1 {
+		t.Errorf("expected the pending patch to be applied as it is, got %d prompts", len(prompts))
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "shapes.go")); string(data) != expected {
+		t.Errorf("unexpected file after applying:\n%s", data)
+	}
+	if pending, _ := processor.PendingPatches(); len(pending) != 0 {
+		t.Errorf("expected the applied patch to be removed, got %v", pending)
+	}
+}
+
+func TestBatchAddCommentsOtherLanguages(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "util.py"), "def add(a,\n        b):\n    return a + b\n\n\ndef _private():\n    pass\n")
+	writeFile(t, filepath.Join(root, "util.js"), "export function add(a, b) {\n  return a + b\n}\n\nfunction local() {}\n")
+
+	reply := `{"add": "Add returns the sum of a and b.\n\nBoth must be numbers."}`
+	processor := newAIBatchProcessor(t, root, reply, nil)
+	runAddComments(t, processor, "util.*", true)
+
+	tests := map[string]string{
+		"util.py": "def add(a,\n        b):\n    \"\"\"Add returns the sum of a and b.\n\n    Both must be numbers.\n    \"\"\"\n    return a + b\n\n\ndef _private():\n    pass\n",
+		"util.js": "/**\n * Add returns the sum of a and b.\n *\n * Both must be numbers.\n */\nexport function add(a, b) {\n  return a + b\n}\n\nfunction local() {}\n",
+	}
+	for file, expected := range tests {
+		if data, _ := os.ReadFile(filepath.Join(root, file)); string(data) != expected {
+			t.Errorf("unexpected %s:\n%s", file, data)
+		}
+	}
+}
+
+func TestBatchAddCommentsPythonOneLiners(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "short.py"), "def f(): return 1\n\ndef g(x):\n    return x\n\nclass C: pass\n")
+
+	reply := `{"f": "Doc for f", "g": "Doc for g", "C": "Doc for C"}`
+	processor := newAIBatchProcessor(t, root, reply, nil)
+	runAddComments(t, processor, "*.py", true)
+
+	expected := "# Doc for f\ndef f(): return 1\n\ndef g(x):\n    \"\"\"Doc for g\"\"\"\n    return x\n\n# Doc for C\nclass C: pass\n"
+	if data, _ := os.ReadFile(filepath.Join(root, "short.py")); string(data) != expected {
+		t.Errorf("expected comments above one-line declarations, got:\n%s", data)
+	}
+}
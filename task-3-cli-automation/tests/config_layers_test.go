@@ -0,0 +1,242 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/config"
+)
+
+func TestLayeredConfigPrecedence(t *testing.T) {
+	root := t.TempDir()
+	userPath := filepath.Join(root, "home", ".k3ss-ai.yaml")
+	workDir := filepath.Join(root, "repo", "services", "api")
+
+	writeFile(t, userPath, "ai:\n  model: user-model\n  timeout: 10\ngit:\n  commit_style: descriptive\n")
+	writeFile(t, filepath.Join(root, "repo", ".k3ss-ai", "config.yaml"), "ai:\n  model: project-model\n")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, origins, err := config.LoadLayered(config.LoadOptions{
+		UserPath: userPath,
+		WorkDir:  workDir,
+		Environ:  []string{"K3SS_AI_AI_TIMEOUT=45", "UNRELATED=1"},
+		Flags:    []config.FlagOverride{{Name: "verbose", Key: "settings.verbose", Value: "true"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectations := []struct {
+		key   string
+		value interface{}
+		layer string
+	}{
+		{"ai.endpoint", "http://localhost:8080", config.LayerDefault},
+		{"git.commit_style", "descriptive", config.LayerUser},
+		{"ai.model", "project-model", config.LayerProject},
+		{"ai.timeout", 45, config.LayerEnv},
+		{"settings.verbose", true, config.LayerFlag},
+	}
+	for _, e := range expectations {
+		value, _ := config.GetValue(cfg, e.key)
+		if value != e.value {
+			t.Errorf("%s = %v, want %v", e.key, value, e.value)
+		}
+		if origins[e.key].Layer != e.layer {
+			t.Errorf("%s came from %s, want %s", e.key, origins[e.key], e.layer)
+		}
+	}
+
+	if _, _, err := config.LoadLayered(config.LoadOptions{UserPath: userPath, WorkDir: workDir, Environ: []string{"K3SS_AI_AI_TIMEOUT=soon"}}); err == nil {
+		t.Error("expected error for invalid environment override")
+	}
+}
+
+func TestLoadConfigDoesNotWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.yaml")
+
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.AI.Model != config.DefaultConfig().AI.Model {
+		t.Errorf("expected defaults, got %+v", cfg.AI)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("LoadConfig must not create the config file")
+	}
+}
+
+func TestConfigFileSetAndUnset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, "# team settings\nai:\n  model: old # pinned\n")
+
+	if err := config.SetFileValue(path, "ai.model", "claude-x"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := config.SetFileValue(path, "git.auto_commit", "true"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := config.SetFileValue(path, "git.auto_commit", "maybe"); err == nil {
+		t.Error("expected error for invalid boolean")
+	}
+
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.AI.Model != "claude-x" || !cfg.Git.AutoCommit {
+		t.Errorf("values not written: %+v", cfg)
+	}
+
+	if err := config.UnsetFileValue(path, "git.auto_commit"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "git:") || !strings.Contains(string(data), "# team settings") {
+		t.Errorf("unexpected file after unset:\n%s", data)
+	}
+}
+
+func TestConfigValidationReportsProblems(t *testing.T) {
+	userPath := filepath.Join(t.TempDir(), ".k3ss-ai.yaml")
+	writeFile(t, userPath, "ai:\n  model: gpt-4\n  timout: 3\n  timeout: -5\ngit:\n  commit_style: conventinal\n")
+
+	_, err := config.LoadConfig(userPath)
+	var validationErr *config.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+
+	want := map[string]int{"ai.timout": 3, "ai.timeout": 4, "git.commit_style": 6}
+	if len(validationErr.Problems) != len(want) {
+		t.Fatalf("expected %d problems, got %v", len(want), validationErr.Problems)
+	}
+	for _, problem := range validationErr.Problems {
+		if line, ok := want[problem.Key]; !ok || problem.Line != line {
+			t.Errorf("unexpected problem %s", problem)
+		}
+	}
+
+	if err := config.SetFileValue(userPath, "settings.output_format", "xml"); err == nil {
+		t.Error("expected SetFileValue to reject an invalid output format")
+	}
+}
+
+func TestConfigMigrateAddsVersion(t *testing.T) {
+	userPath := filepath.Join(t.TempDir(), ".k3ss-ai.yaml")
+	original := "# my settings\nai:\n  model: user-model\n"
+	writeFile(t, userPath, original)
+
+	from, steps, err := config.MigrateFile(userPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if from != 0 || len(steps) == 0 || steps[len(steps)-1].To != config.CurrentVersion {
+		t.Fatalf("unexpected migration from %d: %v", from, steps)
+	}
+
+	backup, err := os.ReadFile(userPath + ".bak")
+	if err != nil || string(backup) != original {
+		t.Fatalf("expected the original to be backed up, got %q (%v)", backup, err)
+	}
+	if version, err := config.FileVersion(userPath); err != nil || version != config.CurrentVersion {
+		t.Errorf("expected version %d after migration, got %d (%v)", config.CurrentVersion, version, err)
+	}
+
+	cfg, err := config.LoadConfig(userPath)
+	if err != nil || cfg.AI.Model != "user-model" {
+		t.Fatalf("expected migrated file to load, got %v", err)
+	}
+
+	if _, steps, err := config.MigrateFile(userPath); err != nil || len(steps) != 0 {
+		t.Errorf("expected a second migration to be a no-op, got %v (%v)", steps, err)
+	}
+}
+
+func TestProfilesAndRouting(t *testing.T) {
+	root := t.TempDir()
+	userPath := filepath.Join(root, ".k3ss-ai.yaml")
+	writeFile(t, userPath, `profile: local
+profiles:
+  local:
+    endpoint: http://localhost:11434
+    model: llama3
+  hosted:
+    endpoint: https://ai.example.com
+    model: gpt-4o-mini
+routing:
+  review:
+    profile: hosted
+    model: gpt-4o
+  git commit:
+    profile: hosted
+`)
+
+	load := func(flags ...config.FlagOverride) (*config.Config, config.Origins) {
+		cfg, origins, err := config.LoadLayered(config.LoadOptions{UserPath: userPath, WorkDir: root, Environ: []string{}, Flags: flags})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return cfg, origins
+	}
+	cfg, _ := load()
+
+	cases := []struct {
+		command, profile, model, endpoint string
+	}{
+		{"chat", "local", "llama3", "http://localhost:11434"},
+		{"review diff", "hosted", "gpt-4o", "https://ai.example.com"},
+		{"git commit", "hosted", "gpt-4o-mini", "https://ai.example.com"},
+	}
+	for _, c := range cases {
+		settings, selection, err := cfg.AIFor(c.command, false)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.command, err)
+		}
+		if selection.Profile != c.profile || settings.Model != c.model || settings.Endpoint != c.endpoint {
+			t.Errorf("%s: got profile %q model %q endpoint %q", c.command, selection.Profile, settings.Model, settings.Endpoint)
+		}
+		if settings.Timeout != 30 {
+			t.Errorf("%s: expected timeout to be inherited from the ai block, got %d", c.command, settings.Timeout)
+		}
+	}
+
+	cfg, origins := load(config.FlagOverride{Name: "profile", Key: "profile", Value: "local"})
+	settings, _, err := cfg.AIFor("review", origins["profile"].Layer == config.LayerFlag)
+	if err != nil || settings.Model != "llama3" {
+		t.Errorf("expected --profile to bypass routing, got %q (%v)", settings.Model, err)
+	}
+
+	_, _, err = config.LoadLayered(config.LoadOptions{
+		UserPath: userPath,
+		WorkDir:  root,
+		Environ:  []string{"K3SS_AI_PROFILE=missing"},
+	})
+	if err == nil || !strings.Contains(err.Error(), `unknown profile "missing"`) {
+		t.Errorf("expected an unknown profile error, got %v", err)
+	}
+
+	if err := config.SetFileValue(userPath, "routing.chat.model", "tiny"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg, _ = load()
+	if settings, _, _ := cfg.AIFor("chat", false); settings.Model != "tiny" {
+		t.Errorf("expected routing.chat.model to apply, got %q", settings.Model)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/config"
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/credentials"
+)
+
+func TestKeystoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	t.Setenv(credentials.KeystoreEnv, path)
+
+	passphrase := func(confirm bool) (string, error) { return "correct horse", nil }
+	provider, err := credentials.New("keystore:ai.api_key", passphrase)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := provider.Set("sk-test-1234567890"); err != nil {
+		t.Fatalf("failed to store secret: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("expected keystore with 0600 permissions, got %v (%v)", info, err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "sk-test") {
+		t.Fatal("keystore contains the secret in plaintext")
+	}
+
+	secret, err := credentials.Resolve("keystore:ai.api_key", passphrase)
+	if err != nil || secret != "sk-test-1234567890" {
+		t.Fatalf("expected stored secret, got %q (%v)", secret, err)
+	}
+
+	_, err = credentials.OpenKeystore(path, "wrong")
+	if !errors.Is(err, credentials.ErrWrongPassphrase) {
+		t.Errorf("expected ErrWrongPassphrase, got %v", err)
+	}
+}
+
+func TestCredentialFileAndEnvProviders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api_key")
+	writeFile(t, path, "sk-from-file\n")
+
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := credentials.Resolve("file:"+path, nil); err == nil {
+		t.Error("expected a world-readable credential file to be rejected")
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if secret, err := credentials.Resolve("file:"+path, nil); err != nil || secret != "sk-from-file" {
+		t.Errorf("expected file secret, got %q (%v)", secret, err)
+	}
+
+	t.Setenv("K3SS_TEST_API_KEY", "sk-from-env")
+	if secret, err := credentials.Resolve("env:K3SS_TEST_API_KEY", nil); err != nil || secret != "sk-from-env" {
+		t.Errorf("expected env secret, got %q (%v)", secret, err)
+	}
+
+	if secret, _ := credentials.Resolve("sk-legacy-plaintext", nil); secret != "sk-legacy-plaintext" {
+		t.Errorf("expected plaintext values to pass through, got %q", secret)
+	}
+}
+
+func TestConfigNeverWritesPlaintextKey(t *testing.T) {
+	userPath := filepath.Join(t.TempDir(), ".k3ss-ai.yaml")
+
+	if err := config.SetFileValue(userPath, "ai.api_key", "sk-plaintext-secret"); err == nil {
+		t.Fatal("expected SetFileValue to refuse a plaintext key")
+	}
+	if err := config.SetFileValue(userPath, "ai.api_key", "keystore:ai.api_key"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	info, err := os.Stat(userPath)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("expected config file with 0600 permissions, got %v (%v)", info, err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.AI.APIKey = "sk-plaintext-secret"
+	if err := config.SaveConfig(cfg, userPath); err == nil {
+		t.Error("expected SaveConfig to refuse a plaintext key")
+	}
+}
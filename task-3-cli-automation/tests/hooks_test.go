@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/automation"
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/git"
+)
+
+func TestHookInstallChainsExistingHook(t *testing.T) {
+	root := t.TempDir()
+	if output, err := exec.Command("git", "-C", root, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, output)
+	}
+	userHook := "#!/bin/sh\necho user hook\n"
+	writeFile(t, filepath.Join(root, ".git", "hooks", "pre-commit"), userHook)
+
+	service := git.NewGitService(root)
+	if err := service.InstallHook("pre-commit", "/usr/local/bin/k3ss-ai"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	status, err := service.HookStatus("pre-commit", "/usr/local/bin/k3ss-ai")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !status.Installed || !status.Current || status.Chained == "" {
+		t.Errorf("expected a current shim chaining the user hook, got %+v", status)
+	}
+	shim, _ := os.ReadFile(status.Path)
+	if !strings.Contains(string(shim), "workflow trigger --event pre-commit") {
+		t.Errorf("expected the shim to trigger pre-commit workflows, got:\n%s", shim)
+	}
+
+	// Installing again must not chain the shim to itself
+	if err := service.InstallHook("pre-commit", "/usr/local/bin/k3ss-ai"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if chained, _ := os.ReadFile(status.Chained); string(chained) != userHook {
+		t.Errorf("expected the user hook to be kept, got:\n%s", chained)
+	}
+
+	if err := service.UninstallHook("pre-commit"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if restored, _ := os.ReadFile(status.Path); string(restored) != userHook {
+		t.Errorf("expected uninstall to restore the user hook, got:\n%s", restored)
+	}
+	if err := service.UninstallHook("pre-commit"); err == nil {
+		t.Error("expected uninstalling a user hook to fail")
+	}
+}
+
+func TestHookInstallResolvesEventAliases(t *testing.T) {
+	root := t.TempDir()
+	if output, err := exec.Command("git", "-C", root, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, output)
+	}
+
+	hooks, branched, err := automation.ResolveHooks([]string{"push", "pre-push", "commit:main"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(hooks, ",") != "pre-push,pre-commit" || strings.Join(branched, ",") != "commit:main" {
+		t.Fatalf("expected each hook once with the branch dropped, got %v (branched %v)", hooks, branched)
+	}
+	if _, _, err := automation.ResolveHooks([]string{"pushed"}); err == nil {
+		t.Error("expected an unknown event to be rejected")
+	}
+
+	service := git.NewGitService(root)
+	for _, hook := range hooks {
+		if err := service.InstallHook(hook, "/usr/local/bin/k3ss-ai"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	for _, name := range []string{"pre-push", "pre-commit"} {
+		if _, err := os.Stat(filepath.Join(root, ".git", "hooks", name)); err != nil {
+			t.Errorf("expected .git/hooks/%s to be installed: %v", name, err)
+		}
+	}
+	for _, name := range []string{"push", "commit:main"} {
+		if _, err := os.Stat(filepath.Join(root, ".git", "hooks", name)); !os.IsNotExist(err) {
+			t.Errorf("expected no .git/hooks/%s, got %v", name, err)
+		}
+	}
+}
+
+func TestTriggerEventMatchesHookAndBranch(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".k3ss-ai", "workflows", "deploy.yaml"), `trigger:
+  type: git_hook
+  events: ["push:main"]
+steps:
+  - name: Deploy
+    command: sh
+    args: ["-c", "echo $K3SS_EVENT"]
+`)
+	writeFile(t, filepath.Join(root, ".k3ss-ai", "workflows", "lint.yaml"), `trigger:
+  type: git_hook
+  events: [pre-commit, pre-push]
+steps:
+  - name: Lint
+    command: "true"
+`)
+
+	service := automation.NewAutomationService(root)
+	if err := service.LoadWorkflows(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bindings := service.HookBindings(); strings.Join(bindings["pre-push"], ",") != "deploy,lint" || strings.Join(bindings["pre-commit"], ",") != "lint" {
+		t.Errorf("unexpected hook bindings: %v", bindings)
+	}
+
+	trigger := func(event, input string) []string {
+		var ran []string
+		err := service.TriggerEvent(context.Background(), event, nil, input, func(name string, result *automation.WorkflowResult, err error) {
+			if err != nil || !result.Success {
+				t.Errorf("workflow %s failed: %v", name, err)
+				return
+			}
+			ran = append(ran, name+":"+strings.TrimSpace(result.Steps[0].Output))
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return ran
+	}
+
+	if ran := trigger("pre-push", "refs/heads/feature abc refs/heads/feature def\n"); strings.Join(ran, ",") != "lint:" {
+		t.Errorf("expected only lint to run for a feature push, got %v", ran)
+	}
+	if ran := trigger("push", "refs/heads/main abc refs/heads/main def\n"); strings.Join(ran, ",") != "deploy:pre-push,lint:" {
+		t.Errorf("expected deploy and lint to run for a push to main, got %v", ran)
+	}
+
+	if _, err := automation.ParseWorkflow([]byte("trigger:\n  type: git_hook\n  events: [on-save]\nsteps:\n  - command: echo\n"), "bad.yaml"); err == nil || !strings.Contains(err.Error(), "bad.yaml:3: unknown git hook event") {
+		t.Errorf("expected an unknown event error on line 3, got %v", err)
+	}
+}
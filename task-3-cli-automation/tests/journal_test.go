@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/automation"
+)
+
+// readFiles returns the content of the .t files written by writeLintScript
+func readFiles(t *testing.T, root string) string {
+	t.Helper()
+	var contents []string
+	for _, name := range []string{"a.t", "b.t", "c.t", "d.t", "e.t"} {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents = append(contents, name+"="+strings.TrimSpace(string(data)))
+	}
+	return strings.Join(contents, " ")
+}
+
+func TestBatchJournalUndo(t *testing.T) {
+	root := t.TempDir()
+	processor := writeLintScript(t, root, `case "$1" in
+a.t|c.t) echo fixed >> "$1" ;;
+esac
+`)
+	if err := os.Chmod(filepath.Join(root, "c.t"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	operation := &automation.BatchOperation{Operation: "lint-fix", Pattern: "*.t", Jobs: 2}
+
+	result, err := processor.ExecuteBatchOperation(operation)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Success || result.RunID == "" {
+		t.Fatalf("expected a journaled run, got %+v", result)
+	}
+	journal, err := automation.LoadJournal(root, result.RunID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(journal.Files) != 2 || journal.Status != automation.JournalDone || journal.Operation != "lint-fix" {
+		t.Fatalf("expected only the changed files journaled, got %+v", journal)
+	}
+	for _, entry := range journal.Files {
+		if entry.OriginalHash == "" || entry.Hash == "" || entry.OriginalHash == entry.Hash {
+			t.Errorf("expected both hashes of %s, got %+v", entry.File, entry)
+		}
+	}
+
+	restored, err := journal.Undo(false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(restored) != 2 || readFiles(t, root) != "a.t= b.t= c.t= d.t= e.t=" {
+		t.Errorf("expected the files restored, got %v: %s", restored, readFiles(t, root))
+	}
+	if info, _ := os.Stat(filepath.Join(root, "c.t")); info.Mode().Perm() != 0600 {
+		t.Errorf("expected the mode restored, got %v", info.Mode())
+	}
+	if _, err := journal.Undo(false); err == nil || !strings.Contains(err.Error(), "already undone") {
+		t.Errorf("expected a second undo to fail, got %v", err)
+	}
+
+	// Files changed again since are only overwritten with force
+	result, _ = processor.ExecuteBatchOperation(operation)
+	writeFile(t, filepath.Join(root, "a.t"), "edited by hand\n")
+	journal, _ = automation.LoadJournal(root, result.RunID)
+	var conflict *automation.ConflictError
+	if _, err := journal.Undo(false); !errors.As(err, &conflict) || strings.Join(conflict.Files, ",") != "a.t" {
+		t.Fatalf("expected a conflict on a.t, got %v", err)
+	}
+	if readFiles(t, root) != "a.t=edited by hand b.t= c.t=fixed d.t= e.t=" {
+		t.Errorf("expected nothing restored after a conflict, got %s", readFiles(t, root))
+	}
+	if _, err := journal.Undo(true); err != nil || readFiles(t, root) != "a.t= b.t= c.t= d.t= e.t=" {
+		t.Errorf("expected force to restore the files, got %v: %s", err, readFiles(t, root))
+	}
+
+	journals, err := automation.LoadJournals(root)
+	if err != nil || len(journals) != 2 || journals[0].ID != result.RunID {
+		t.Errorf("expected both runs listed newest first, got %v (%v)", journals, err)
+	}
+}
+
+func TestBatchAtomicRollsBack(t *testing.T) {
+	root := t.TempDir()
+	processor := writeLintScript(t, root, `echo fixed >> "$1"
+[ "$1" = c.t ] && exit 1
+exit 0
+`)
+
+	result, err := processor.ExecuteBatchOperation(&automation.BatchOperation{
+		Operation: "lint-fix",
+		Pattern:   "*.t",
+		Jobs:      1,
+		Atomic:    true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Success || result.FilesCancelled != 2 || result.FilesProcessed != 0 || strings.Join(result.RolledBack, ",") != "c.t,b.t,a.t" {
+		t.Errorf("expected the failure to cancel the rest and roll back the changed files, got %+v", result)
+	}
+	if got := readFiles(t, root); got != "a.t= b.t= c.t= d.t= e.t=" {
+		t.Errorf("expected no file changed, got %s", got)
+	}
+	journal, err := automation.LoadJournal(root, result.RunID)
+	if err != nil || journal.Status != automation.JournalRolledBack {
+		t.Errorf("expected the journal marked rolled back, got %+v (%v)", journal, err)
+	}
+}
+
+func TestBatchJournalRemovesCreatedFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "util.py"), "def add(a, b):\n    return a + b\n")
+	processor := newAIBatchProcessor(t, root, "def test_add():\n    pass\n", nil)
+
+	result, err := processor.RunBatchOperation(context.Background(), &automation.BatchOperation{Operation: "add-tests", Pattern: "*.py"})
+	if err != nil || !result.Success {
+		t.Fatalf("expected add-tests to succeed, got %+v (%v)", result, err)
+	}
+	journal, err := automation.LoadJournal(root, result.RunID)
+	if err != nil || len(journal.Files) != 1 || !journal.Files[0].Created || journal.Files[0].File != "test_util.py" {
+		t.Fatalf("expected the test file journaled as created, got %+v (%v)", journal, err)
+	}
+	if _, err := journal.Undo(false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "test_util.py")); !os.IsNotExist(err) {
+		t.Errorf("expected undo to remove the created file, got %v", err)
+	}
+}
+
+func TestJournalRecoversUnfinishedRun(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.t"), "original\n")
+	writeFile(t, filepath.Join(root, "b.t"), "same\n")
+
+	journal, err := automation.NewJournal(root, "format")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, file := range []string{"a.t", "b.t", "new.t"} {
+		if err := journal.Track(file); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	writeFile(t, filepath.Join(root, "a.t"), "formatted\n")
+	writeFile(t, filepath.Join(root, "new.t"), "created\n")
+	for _, file := range []string{"a.t", "b.t", "new.t"} {
+		if err := journal.Settle(file); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// The run stops before Finish; its files are read back from the log
+	loaded, err := automation.LoadJournal(root, journal.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loaded.Status != automation.JournalRunning || len(loaded.Files) != 2 || loaded.Files[0].File != "a.t" || loaded.Files[0].Hash == "" || !loaded.Files[1].Created {
+		t.Fatalf("expected the changed files read back from the log, got %+v", loaded)
+	}
+	if _, err := loaded.Undo(false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "a.t")); string(data) != "original\n" {
+		t.Errorf("expected a.t restored, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(root, "new.t")); !os.IsNotExist(err) {
+		t.Errorf("expected new.t removed, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, automation.JournalDir, journal.ID, "journal.log")); !os.IsNotExist(err) {
+		t.Errorf("expected the log folded into journal.json, got %v", err)
+	}
+	if reloaded, err := automation.LoadJournal(root, journal.ID); err != nil || reloaded.Status != automation.JournalUndone || len(reloaded.Files) != 2 {
+		t.Errorf("expected the undone journal saved, got %+v (%v)", reloaded, err)
+	}
+}
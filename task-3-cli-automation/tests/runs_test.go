@@ -0,0 +1,76 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/automation"
+)
+
+func TestRunHistoryRecordsAndPrunes(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".k3ss-ai", "workflows", "build.yaml"), `steps:
+  - id: compile
+    command: sh
+    args: ["-c", "echo compiling; echo warning >&2; echo version=1 >> $K3SS_OUTPUT"]
+  - id: check
+    command: "false"
+    continue_on_error: true
+`)
+
+	service := automation.NewAutomationService(root)
+	service.SetRetention(automation.Retention{KeepRuns: 2})
+	if err := service.LoadWorkflows(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		result, err := service.ExecuteWorkflow("build")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, result.RunID)
+	}
+
+	runs, err := service.RunHistory("build")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(runs) != 2 || runs[0].ID != ids[2] || runs[1].ID != ids[1] {
+		t.Fatalf("expected the two newest runs, newest first, got %+v", runs)
+	}
+
+	run, err := service.FindRun(ids[2])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if run.Status != "success" || run.Trigger != "manual" || len(run.Steps) != 2 {
+		t.Errorf("unexpected run record: %+v", run)
+	}
+	if run.Steps[0].Outputs["version"] != "1" || run.Steps[1].Status != "failure" {
+		t.Errorf("unexpected step records: %+v", run.Steps)
+	}
+	if log, err := service.StepLog(run, run.Steps[0], ""); err != nil || !strings.Contains(log, "compiling\n") || !strings.Contains(log, "warning\n") {
+		t.Errorf("expected the step log, got %q, %v", log, err)
+	}
+	if log, err := service.StepLog(run, run.Steps[0], "stdout"); err != nil || log != "compiling\n" {
+		t.Errorf("expected the stdout log, got %q, %v", log, err)
+	}
+	if log, err := service.StepLog(run, run.Steps[0], "stderr"); err != nil || log != "warning\n" {
+		t.Errorf("expected the stderr log, got %q, %v", log, err)
+	}
+	if _, err := service.FindRun(ids[0]); err == nil {
+		t.Error("expected the oldest run to be pruned")
+	}
+
+	reloaded := automation.NewAutomationService(root)
+	if err := reloaded.LoadWorkflows(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	workflow, _ := reloaded.GetWorkflow("build")
+	if !workflow.LastRun.Equal(run.StartTime) {
+		t.Errorf("expected LastRun %v from history, got %v", run.StartTime, workflow.LastRun)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/automation"
+)
+
+// runSandboxed runs the single workflow in root with sandbox and returns its steps
+func runSandboxed(t *testing.T, root string, sandbox *automation.Sandbox) []automation.StepResult {
+	t.Helper()
+	service := automation.NewAutomationService(root)
+	service.SetStepOutput(nil, nil)
+	service.SetSandbox(sandbox)
+	if err := service.LoadWorkflows(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := service.ExecuteWorkflow("sandboxed")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return result.Steps
+}
+
+func TestSandboxScrubsEnvironment(t *testing.T) {
+	t.Setenv("K3SS_TEST_SECRET", "hunter2")
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".k3ss-ai", "workflows", "sandboxed.yaml"), `environment:
+  STAGE: test
+steps:
+  - id: env
+    command: sh
+    args: ["-c", "echo secret=$K3SS_TEST_SECRET stage=$STAGE path=${PATH:+set}"]
+`)
+
+	steps := runSandboxed(t, root, &automation.Sandbox{})
+	step := steps[0]
+	if !step.Success || strings.TrimSpace(step.Stdout) != "secret= stage=test path=set" {
+		t.Fatalf("expected only allowlisted and workflow variables, got %q (%v)", step.Stdout, step.Error)
+	}
+	if step.Sandbox == nil || step.Sandbox.EnvWithheld == 0 {
+		t.Errorf("expected the report to count withheld variables, got %+v", step.Sandbox)
+	}
+
+	steps = runSandboxed(t, root, &automation.Sandbox{EnvAllowlist: []string{"PATH", "K3SS_TEST_*"}})
+	if got := strings.TrimSpace(steps[0].Stdout); got != "secret=hunter2 stage=test path=set" {
+		t.Errorf("expected the wildcard to pass the variable, got %q", got)
+	}
+}
+
+func TestSandboxAppliesResourceLimits(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("resource limits are only applied on Linux")
+	}
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".k3ss-ai", "workflows", "sandboxed.yaml"), `steps:
+  - id: limits
+    command: sh
+    args: ["-c", "ulimit -n; ulimit -t; ulimit -v"]
+  - id: missing
+    command: no-such-command-k3ss
+`)
+
+	steps := runSandboxed(t, root, &automation.Sandbox{
+		CPUTime:   90 * time.Second,
+		Memory:    1 << 30,
+		OpenFiles: 64,
+	})
+	limits := steps[0]
+	if !limits.Success || limits.Stdout != "64\n90\n1048576\n" {
+		t.Fatalf("expected the limits to apply to the step, got %q (%v)", limits.Stdout, limits.Error)
+	}
+	report := limits.Sandbox
+	if report == nil || report.CPUTime != 90*time.Second || report.Memory != 1<<30 || report.OpenFiles != 64 || len(report.Fallbacks) != 0 {
+		t.Errorf("expected every limit reported as applied, got %+v", report)
+	}
+
+	missing := steps[1]
+	if missing.Success || missing.Error == nil || !strings.Contains(missing.Error.Error(), "executable file not found") {
+		t.Errorf("expected a missing command to fail to start, got %+v", missing)
+	}
+}
+
+func TestSandboxReadOnlyPaths(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("read-only paths are only applied on Linux")
+	}
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "locked", "config.txt"), "original\n")
+	writeFile(t, filepath.Join(root, ".k3ss-ai", "workflows", "sandboxed.yaml"), `steps:
+  - id: write-locked
+    command: sh
+    args: ["-c", "echo changed > locked/config.txt"]
+    continue_on_error: true
+  - id: write-open
+    command: sh
+    args: ["-c", "echo ok > open.txt"]
+`)
+
+	steps := runSandboxed(t, root, &automation.Sandbox{ReadOnlyPaths: []string{"locked", "missing"}})
+	report := steps[0].Sandbox
+	if report == nil {
+		t.Fatal("expected a sandbox report")
+	}
+	if len(report.ReadOnlyPaths) == 0 {
+		t.Skipf("mount namespaces unavailable: %v", report.Fallbacks)
+	}
+
+	if steps[0].Success {
+		t.Error("expected writing to a read-only path to fail")
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "locked", "config.txt")); string(data) != "original\n" {
+		t.Errorf("expected the read-only file to be unchanged, got %q", data)
+	}
+	if !steps[1].Success {
+		t.Errorf("expected writes elsewhere to succeed, got %v", steps[1].Error)
+	}
+	if len(report.Fallbacks) != 1 || !strings.Contains(report.Fallbacks[0], "missing") {
+		t.Errorf("expected the missing path reported as a fallback, got %v", report.Fallbacks)
+	}
+}
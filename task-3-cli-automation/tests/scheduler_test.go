@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/automation"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	at := func(s string) time.Time {
+		parsed, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	cases := []struct {
+		expr, from, want string
+	}{
+		{"*/15 * * * *", "2024-03-10 10:07", "2024-03-10 10:15"},
+		{"0 9-17 * * mon-fri", "2024-03-08 17:30", "2024-03-11 09:00"}, // Friday evening to Monday
+		{"30 2 1 * *", "2024-01-31 12:00", "2024-02-01 02:30"},
+		{"0 0 29 feb *", "2024-03-01 00:00", "2028-02-29 00:00"},
+		{"0 12 13 * fri", "2024-09-01 00:00", "2024-09-06 12:00"}, // day of month or weekday
+		{"0 0 * * 7", "2024-03-10 00:00", "2024-03-17 00:00"},     // 7 is Sunday
+		{"0 0 */2 * mon", "2024-03-02 00:00", "2024-03-11 00:00"}, // a day field starting with * is not a restriction
+		{"@hourly", "2024-03-10 10:59", "2024-03-10 11:00"},
+		{"5,10 4/6 * * *", "2024-03-10 10:05", "2024-03-10 10:10"},
+	}
+	for _, c := range cases {
+		schedule, err := automation.ParseSchedule(c.expr)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q): unexpected error: %v", c.expr, err)
+			continue
+		}
+		if got := schedule.Next(at(c.from)); !got.Equal(at(c.want)) {
+			t.Errorf("%q after %s: got %s, want %s", c.expr, c.from, got.Format("2006-01-02 15:04"), c.want)
+		}
+	}
+
+	for _, expr := range []string{"* * * *", "60 * * * *", "* * * foo *", "5-1 * * * *", "*/0 * * * *", "@often"} {
+		if _, err := automation.ParseSchedule(expr); err == nil {
+			t.Errorf("expected %q to be rejected", expr)
+		}
+	}
+}
+
+func TestSchedulerDetectsAndRunsMissedRuns(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".k3ss-ai", "workflows", "hourly.yaml"), `trigger:
+  type: schedule
+  pattern: "0 * * * *"
+steps:
+  - command: "true"
+`)
+	lastFire := time.Now().Add(-3 * time.Hour).Truncate(time.Hour)
+	writeFile(t, filepath.Join(root, ".k3ss-ai", "scheduler.json"),
+		`{"workflows": {"hourly": {"last_fire": "`+lastFire.Format(time.RFC3339)+`", "success": true}}}`)
+
+	service := automation.NewAutomationService(root)
+	if err := service.LoadWorkflows(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	scheduled, err := service.ScheduledWorkflows(time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(scheduled) != 1 || scheduled[0].Missed != 3 {
+		t.Fatalf("expected 3 missed runs, got %+v", scheduled)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ran := make(chan string, 1)
+	go service.RunScheduler(ctx, automation.SchedulerOptions{
+		OnResult: func(name string, fire time.Time, result *automation.WorkflowResult, err error) {
+			if err != nil || !result.Success {
+				t.Errorf("scheduled run failed: %v", err)
+			}
+			ran <- name
+		},
+	})
+
+	select {
+	case name := <-ran:
+		if name != "hourly" {
+			t.Errorf("expected hourly to catch up, got %s", name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("missed run was not caught up")
+	}
+	cancel()
+
+	// The catch-up run is recorded, so nothing is missed any more
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		scheduled, err = service.ScheduledWorkflows(time.Now())
+		if err == nil && scheduled[0].Missed == 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil || scheduled[0].Missed != 0 {
+		t.Errorf("expected the catch-up run to be recorded, got %+v, %v", scheduled, err)
+	}
+
+	if _, err := automation.ParseWorkflow([]byte("trigger:\n  type: schedule\n  pattern: \"0 25 * * *\"\nsteps:\n  - command: echo\n"), "bad.yaml"); err == nil || !strings.Contains(err.Error(), "bad.yaml:3: invalid cron expression") {
+		t.Errorf("expected an invalid cron error on line 3, got %v", err)
+	}
+}
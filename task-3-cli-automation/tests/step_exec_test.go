@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/automation"
+)
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of streaming steps
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestStepOutputStreamsWithPrefix(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".k3ss-ai", "workflows", "build.yaml"), `steps:
+  - name: compile
+    command: sh
+    args: ["-c", "echo one; echo oops >&2; printf two"]
+`)
+
+	service := automation.NewAutomationService(root)
+	var stdout, stderr syncBuffer
+	service.SetStepOutput(&stdout, &stderr)
+	if err := service.LoadWorkflows(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := service.ExecuteWorkflow("build")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	step := result.Steps[0]
+	if !step.Success || step.Stdout != "one\ntwo" || step.Stderr != "oops\n" {
+		t.Errorf("expected separate stdout and stderr, got %+v", step)
+	}
+	if !strings.Contains(step.Output, "one\n") || !strings.Contains(step.Output, "oops\n") {
+		t.Errorf("expected the combined output, got %q", step.Output)
+	}
+	if got := stdout.String(); got != "    [compile] one\n    [compile] two\n" {
+		t.Errorf("unexpected streamed stdout %q", got)
+	}
+	if got := stderr.String(); got != "    [compile] oops\n" {
+		t.Errorf("unexpected streamed stderr %q", got)
+	}
+}
+
+func TestStepTimeoutInterruptsProcessGroup(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".k3ss-ai", "workflows", "slow.yaml"), `steps:
+  - id: wait
+    command: sh
+    args: ["-c", "sleep 30; echo done"]
+    timeout: 200ms
+  - id: after
+    command: "true"
+    needs: [wait]
+`)
+
+	service := automation.NewAutomationService(root)
+	service.SetStepOutput(nil, nil)
+	if err := service.LoadWorkflows(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	result, err := service.ExecuteWorkflow("slow")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("expected the step to be interrupted promptly, took %v", elapsed)
+	}
+	if result.Success || result.Steps[0].Error == nil || !strings.Contains(result.Steps[0].Error.Error(), "timed out after 200ms") {
+		t.Errorf("expected the step to time out, got %+v", result.Steps[0])
+	}
+	if !result.Steps[1].Skipped {
+		t.Errorf("expected the dependent step to be skipped, got %+v", result.Steps[1])
+	}
+}
+
+func TestCancelledRunSkipsRemainingSteps(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".k3ss-ai", "workflows", "long.yaml"), `steps:
+  - id: first
+    command: sleep
+    args: ["30"]
+  - id: second
+    command: "true"
+    needs: [first]
+`)
+
+	service := automation.NewAutomationService(root)
+	service.SetStepOutput(nil, nil)
+	if err := service.LoadWorkflows(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	result, err := service.RunWorkflow(ctx, "long", automation.RunOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Success || result.Steps[0].Error == nil || !strings.Contains(result.Steps[0].Error.Error(), "cancelled") {
+		t.Errorf("expected the running step to be cancelled, got %+v", result.Steps[0])
+	}
+	if !result.Steps[1].Skipped {
+		t.Errorf("expected the remaining step to be skipped, got %+v", result.Steps[1])
+	}
+}
+
+func TestStepTimeoutMustBeAPositiveDuration(t *testing.T) {
+	for _, timeout := range []string{"10", "-5s"} {
+		_, err := automation.ParseWorkflow([]byte("steps:\n  - command: make\n    timeout: "+timeout+"\n"), "build.yaml")
+		if err == nil || !strings.Contains(err.Error(), "build.yaml:3") {
+			t.Errorf("expected an error at line 3 for timeout %s, got %v", timeout, err)
+		}
+	}
+}
+
+func TestStepRetryPolicy(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".k3ss-ai", "workflows", "deploy.yaml"), `steps:
+  - id: flaky
+    command: sh
+    args: ["-c", "n=$(cat count 2>/dev/null || echo 0); n=$((n+1)); echo $n > count; echo try $n; [ $n -ge 3 ]"]
+    retry:
+      attempts: 4
+      backoff: 10ms
+  - id: broken
+    command: sh
+    args: ["-c", "exit 1"]
+    retry:
+      attempts: 3
+      on_exit_codes: [75]
+    needs: [flaky]
+`)
+
+	service := automation.NewAutomationService(root)
+	service.SetStepOutput(nil, nil)
+	if err := service.LoadWorkflows(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := service.ExecuteWorkflow("deploy")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	flaky := result.Steps[0]
+	if !flaky.Success || len(flaky.Attempts) != 3 || flaky.Attempts[0].ExitCode != 1 || flaky.Attempts[2].ExitCode != 0 {
+		t.Errorf("expected the step to succeed on its third attempt, got %+v", flaky)
+	}
+	if !strings.Contains(flaky.Output, "try 1") || !strings.Contains(flaky.Output, "try 3") {
+		t.Errorf("expected the output of every attempt, got %q", flaky.Output)
+	}
+	if broken := result.Steps[1]; broken.Success || len(broken.Attempts) != 1 {
+		t.Errorf("expected exit code 1 not to be retried, got %+v", broken)
+	}
+
+	run, err := service.FindRun(result.RunID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(run.Steps[0].Attempts) != 3 {
+		t.Errorf("expected the attempts to be recorded, got %+v", run.Steps[0])
+	}
+}
+
+func TestFailureHandlers(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".k3ss-ai", "workflows", "release.yaml"), `steps:
+  - id: build
+    command: "false"
+on_failure:
+  - id: notify
+    command: sh
+    args: ["-c", "echo build ${{ steps.build.result }} >> handlers"]
+always:
+  - command: sh
+    args: ["-c", "echo cleanup >> handlers"]
+`)
+	writeFile(t, filepath.Join(root, ".k3ss-ai", "workflows", "ok.yaml"), `steps:
+  - command: "true"
+on_failure:
+  - command: sh
+    args: ["-c", "echo unexpected >> ok-handlers"]
+always:
+  - command: sh
+    args: ["-c", "echo cleanup >> ok-handlers"]
+`)
+
+	service := automation.NewAutomationService(root)
+	service.SetStepOutput(nil, nil)
+	if err := service.LoadWorkflows(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := service.ExecuteWorkflow("release")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Success || len(result.Steps) != 3 || result.Steps[1].Phase != "on_failure" || result.Steps[2].StepID != "always-1" {
+		t.Errorf("expected the failure and cleanup handlers to run, got %+v", result.Steps)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "handlers")); string(data) != "build failure\ncleanup\n" {
+		t.Errorf("unexpected handler output %q", data)
+	}
+
+	result, err = service.ExecuteWorkflow("ok")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Success || len(result.Steps) != 2 {
+		t.Errorf("expected only the always handler to run, got %+v", result.Steps)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "ok-handlers")); string(data) != "cleanup\n" {
+		t.Errorf("unexpected handler output %q", data)
+	}
+}
+
+func TestInvalidRetryAndHandlersReported(t *testing.T) {
+	_, err := automation.ParseWorkflow([]byte(`steps:
+  - id: build
+    command: make
+    retry:
+      attempts: 0
+always:
+  - id: build
+    command: rm
+  - command: echo
+    needs: [build]
+`), "build.yaml")
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{"build.yaml:5", "attempts must be at least 1", "build.yaml:7", `"build" is already used`, "build.yaml:10", "cannot use needs"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/automation"
+)
+
+func TestUsesFileTemplateWithInputs(t *testing.T) {
+	root := t.TempDir()
+	workflows := filepath.Join(root, ".k3ss-ai", "workflows")
+	writeFile(t, filepath.Join(workflows, "templates", "greet.yaml"), `inputs:
+  who:
+    required: true
+  greeting:
+    default: hello
+environment:
+  PUNCTUATION: "!"
+steps:
+  - id: compose
+    command: sh
+    args: ["-c", "echo message=${{ inputs.greeting }} ${{ inputs.who }}$PUNCTUATION >> $K3SS_OUTPUT"]
+  - id: print
+    command: sh
+    args: ["-c", "echo '${{ steps.compose.outputs.message }}' >> greetings"]
+`)
+	writeFile(t, filepath.Join(workflows, "release.yaml"), `steps:
+  - id: version
+    command: sh
+    args: ["-c", "echo number=1.2 >> $K3SS_OUTPUT"]
+  - id: greet
+    uses: ./templates/greet.yaml
+    with:
+      who: "v${{ steps.version.outputs.number }}"
+  - id: after
+    command: sh
+    args: ["-c", "echo 'after ${{ steps.greet.result }} ${{ steps.greet.outputs.message }}' >> greetings"]
+`)
+
+	service := automation.NewAutomationService(root)
+	service.SetStepOutput(nil, nil)
+	if err := service.LoadWorkflows(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := service.ExecuteWorkflow("release")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("expected the workflow to succeed, got %v", result.Error)
+	}
+	var ids []string
+	for _, step := range result.Steps {
+		ids = append(ids, step.StepID)
+	}
+	if got := strings.Join(ids, " "); got != "version greet/compose greet/print greet after" {
+		t.Errorf("unexpected expanded steps %q", got)
+	}
+	data, _ := os.ReadFile(filepath.Join(root, "greetings"))
+	if string(data) != "hello v1.2!\nafter success hello v1.2!\n" {
+		t.Errorf("unexpected output %q", data)
+	}
+}
+
+func TestUsesTemplateErrorsReportedAtLoad(t *testing.T) {
+	root := t.TempDir()
+	workflows := filepath.Join(root, ".k3ss-ai", "workflows")
+	writeFile(t, filepath.Join(workflows, "loop.yaml"), `inputs: {}
+steps:
+  - uses: ./loop.yaml
+`)
+	writeFile(t, filepath.Join(workflows, "missing.yaml"), `steps:
+  - command: "true"
+  - uses: builtin/quality/go
+    with:
+      colour: blue
+`)
+	writeFile(t, filepath.Join(workflows, "alias.yaml"), `steps:
+  - uses: builtin/go-quality
+    with:
+      colour: blue
+`)
+	writeFile(t, filepath.Join(workflows, "mixed.yaml"), `steps:
+  - uses: builtin/quality
+    command: make
+`)
+
+	service := automation.NewAutomationService(root)
+	if err := service.LoadWorkflows(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"loop":    "template cycle",
+		"missing": `missing.yaml:3: step "step-2": builtin/quality/go: unknown input "colour"`,
+		"mixed":   "cannot set command together with uses",
+		"alias":   `builtin/go-quality: unknown input "colour" (expected one of packages)`,
+	} {
+		if _, err := service.ExecuteWorkflow(name); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected %q, got %v", name, want, err)
+		}
+	}
+}
+
+func TestPrebuiltWorkflowsFollowBuildSystem(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "Cargo.toml"), "[package]\nname = \"demo\"\n")
+
+	service := automation.NewAutomationService(root)
+	workflows, err := service.CreatePrebuiltWorkflows("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(workflows) != 2 {
+		t.Fatalf("expected two workflows, got %d", len(workflows))
+	}
+	data, err := os.ReadFile(filepath.Join(root, ".k3ss-ai", "workflows", "quality-check.yaml"))
+	if err != nil || !strings.Contains(string(data), "uses: builtin/quality/cargo") {
+		t.Errorf("expected the cargo flavour, got %s (%v)", data, err)
+	}
+
+	if _, err := service.CreatePrebuiltWorkflows("ant"); err == nil || !strings.Contains(err.Error(), "available: cargo, go, maven, npm") {
+		t.Errorf("expected the available flavours to be listed, got %v", err)
+	}
+}
+
+func TestPrebuiltWorkflowsDetectFlavour(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		files   []string
+		flavour string
+	}{
+		{"makefile", []string{"Makefile", "go.mod"}, "go"},
+		{"empty", nil, automation.DefaultFlavour},
+	} {
+		root := t.TempDir()
+		for _, file := range test.files {
+			writeFile(t, filepath.Join(root, file), "\n")
+		}
+		writeFile(t, filepath.Join(root, ".k3ss-ai", "workflows", "checks.yaml"), `steps:
+  - id: checks
+    uses: builtin/quality
+`)
+
+		service := automation.NewAutomationService(root)
+		if _, err := service.CreatePrebuiltWorkflows(""); err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		data, err := os.ReadFile(filepath.Join(root, ".k3ss-ai", "workflows", "quality-check.yaml"))
+		if err != nil || !strings.Contains(string(data), "uses: builtin/quality/"+test.flavour) {
+			t.Errorf("%s: expected the %s flavour, got %s (%v)", test.name, test.flavour, data, err)
+		}
+		if err := service.LoadWorkflows(); err != nil {
+			t.Errorf("%s: expected builtin/quality to load, got %v", test.name, err)
+		}
+		if workflow, err := service.GetWorkflow("checks"); err != nil || !strings.Contains(workflow.Steps[0].Uses, "builtin/quality") {
+			t.Errorf("%s: expected the checks workflow, got %v", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/automation"
+)
+
+func TestGenerateGoTestsVetted(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/shapes\n\ngo 1.21\n")
+	writeFile(t, filepath.Join(root, "geometry", "area.go"), `package geometry
+
+func Square(side int) int { return side * side }
+
+func (r Rect) Area() int { return r.W * r.H }
+
+type Rect struct{ W, H int }
+
+func double(n int) int { return 2 * n }
+`)
+
+	valid := "package geometry\n\nimport \"testing\"\n\nfunc TestSquare(t *testing.T) {\n\ttests := []struct{ side, want int }{{2, 4}, {3, 9}}\n\tfor _, tt := range tests {\n\t\tif got := Square(tt.side); got != tt.want {\n\t\t\tt.Errorf(\"Square(%d) = %d, want %d\", tt.side, got, tt.want)\n\t\t}\n\t}\n}\n"
+	tests := []struct {
+		name  string
+		reply string
+		err   string
+	}{
+		{"wrong package", "package main\n", "declare package main, not geometry"},
+		{"fails vet", "package geometry\n\nimport \"testing\"\n\nfunc TestSquare(t *testing.T) { t.Errorf(\"%d\") }\n", "fail go vet"},
+		{"valid", "Here are the tests:\n```go\n" + valid + "```\n", ""},
+	}
+	for _, tt := range tests {
+		var prompts []string
+		processor := newAIBatchProcessor(t, root, tt.reply, &prompts)
+		testFile, err := processor.GenerateTests(context.Background(), filepath.Join("geometry", "area.go"), automation.TestOptions{})
+		written, readErr := os.ReadFile(filepath.Join(root, "geometry", "area_test.go"))
+
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: expected an error containing %q, got %v", tt.name, tt.err, err)
+			}
+			if readErr == nil {
+				t.Errorf("%s: expected nothing written, got %q", tt.name, written)
+			}
+			var failed *automation.CommandError
+			if errors.As(err, &failed) && !strings.Contains(failed.Output, "area_test.go") {
+				t.Errorf("%s: expected go vet's output to name the test file, got %q", tt.name, failed.Output)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if testFile != filepath.Join("geometry", "area_test.go") || string(written) != valid {
+			t.Errorf("%s: expected the code block written to %s, got %s:\n%s", tt.name, testFile, testFile, written)
+		}
+		prompt := prompts[0]
+		if !strings.Contains(prompt, "table-driven unit tests using testing") || !strings.Contains(prompt, "`package geometry`") ||
+			!strings.Contains(prompt, "- Square:") || !strings.Contains(prompt, "- Rect.Area:") || strings.Contains(prompt, "double") {
+			t.Errorf("%s: unexpected prompt %q", tt.name, prompt)
+		}
+	}
+}
+
+func TestBatchAddTestsOtherLanguages(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "src", "util.py"), "def add(a, b):\n    return a + b\n")
+	writeFile(t, filepath.Join(root, "src", "lib.ts"), "export const add = (a: number, b: number) => a + b\n")
+	writeFile(t, filepath.Join(root, "src", "lib.spec.ts"), "// existing tests\n")
+
+	var prompts []string
+	processor := newAIBatchProcessor(t, root, "```\n# generated\n```", &prompts)
+	result, err := processor.RunBatchOperation(context.Background(), &automation.BatchOperation{
+		Operation: "add-tests",
+		Pattern:   "src/*",
+		Jobs:      1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("expected add-tests to succeed, got %+v", result.Errors)
+	}
+
+	if len(prompts) != 2 || !strings.Contains(prompts[0], "from './lib'") || !strings.Contains(prompts[0], "using jest") ||
+		!strings.Contains(prompts[1], "`from util import ...`") || !strings.Contains(prompts[1], "using pytest") {
+		t.Errorf("expected one prompt per source file with its imports, got %q", prompts)
+	}
+	for _, file := range []string{"lib.test.ts", "test_util.py"} {
+		if data, _ := os.ReadFile(filepath.Join(root, "src", file)); string(data) != "# generated\n" {
+			t.Errorf("expected src/%s to be written, got %q", file, data)
+		}
+	}
+}
+
+func TestBatchAddTestsVetsPackageTogether(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/shapes\n\ngo 1.21\n")
+	writeFile(t, filepath.Join(root, "geometry", "square.go"), "package geometry\n\nfunc Square(side int) int { return side * side }\n")
+	writeFile(t, filepath.Join(root, "geometry", "cube.go"), "package geometry\n\nfunc Cube(side int) int { return side * side * side }\n")
+
+	// Each reply passes go vet alone, but not next to the other
+	reply := "package geometry\n\nimport \"testing\"\n\ntype testCase struct{ side, want int }\n\nfunc TestGenerated(t *testing.T) { _ = []testCase{} }\n"
+	processor := newAIBatchProcessor(t, root, reply, nil)
+	result, err := processor.RunBatchOperation(context.Background(), &automation.BatchOperation{Operation: "add-tests", Pattern: "geometry/*.go", Jobs: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.FilesProcessed != 1 || len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Error, "fail go vet") {
+		t.Fatalf("expected the second test file to fail go vet, got %+v", result)
+	}
+
+	vet := exec.Command("go", "vet", "./geometry")
+	vet.Dir = root
+	if output, err := vet.CombinedOutput(); err != nil {
+		t.Errorf("expected the package to pass go vet, got %v:\n%s", err, output)
+	}
+}
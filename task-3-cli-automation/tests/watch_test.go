@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/automation"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".gitignore"), "# build output\nbuild/\n*.log\n!keep.log\n/vendor\ndocs/**/*.tmp\n")
+	writeFile(t, filepath.Join(root, "web", ".gitignore"), "dist\n")
+
+	matcher := automation.NewIgnoreMatcher(root, ".gitignore")
+	cases := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"build", true, true},
+		{"build/main.go", false, true},
+		{"src/build", false, false}, // build/ only matches directories
+		{"debug.log", false, true},
+		{"logs/keep.log", false, false},
+		{"vendor/pkg/a.go", false, true},
+		{"src/vendor/a.go", false, false},
+		{"docs/a/b/c.tmp", false, true},
+		{"web/dist/app.js", false, true},
+		{"dist/app.js", false, false},
+		{".git/config", false, true},
+		{"cmd/main.go", false, false},
+	}
+	for _, c := range cases {
+		if got := matcher.Ignored(c.path, c.isDir); got != c.ignored {
+			t.Errorf("Ignored(%q) = %v, want %v", c.path, got, c.ignored)
+		}
+	}
+
+	if !automation.MatchGlob("**/*.go", "cmd/main.go") || !automation.MatchGlob("*.go", "internal/a/b.go") || automation.MatchGlob("src/*.go", "src/a/b.go") {
+		t.Error("unexpected MatchGlob result")
+	}
+}
+
+func TestWatchRunsMatchingWorkflows(t *testing.T) {
+	for _, poll := range []bool{false, true} {
+		name := "native"
+		if poll {
+			name = "poll"
+		}
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFile(t, filepath.Join(root, ".gitignore"), "build/\n")
+			writeFile(t, filepath.Join(root, ".k3ss-ai", "workflows", "go-changed.yaml"), `trigger:
+  type: file_change
+  pattern: "**/*.go"
+steps:
+  - name: List changes
+    command: sh
+    args: ["-c", "printf '%s' \"$K3SS_CHANGED_FILES\""]
+`)
+			writeFile(t, filepath.Join(root, "src", "a.go"), "package src\n")
+
+			service := automation.NewAutomationService(root)
+			if err := service.LoadWorkflows(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			results := make(chan *automation.WorkflowResult, 10)
+			ctx, cancel := context.WithCancel(context.Background())
+			stopped := make(chan error)
+			go func() {
+				stopped <- service.Watch(ctx, automation.WatchOptions{
+					Poll:     poll,
+					Interval: 50 * time.Millisecond,
+					Debounce: 200 * time.Millisecond,
+					OnResult: func(name string, result *automation.WorkflowResult, err error) {
+						if err != nil {
+							t.Errorf("unexpected error: %v", err)
+							return
+						}
+						results <- result
+					},
+				})
+			}()
+			defer func() {
+				cancel()
+				if err := <-stopped; err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}()
+			time.Sleep(200 * time.Millisecond)
+
+			writeFile(t, filepath.Join(root, "build", "gen.go"), "package build\n")
+			writeFile(t, filepath.Join(root, "notes.md"), "notes\n")
+			writeFile(t, filepath.Join(root, "src", "a.go"), "package src\n\nfunc A() {}\n")
+			writeFile(t, filepath.Join(root, "src", "new", "b.go"), "package new\n")
+
+			select {
+			case result := <-results:
+				if got := result.Steps[0].Output; got != "src/a.go\nsrc/new/b.go" {
+					t.Errorf("expected the changed Go files outside build/, got %q", got)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("workflow was not triggered")
+			}
+
+			select {
+			case result := <-results:
+				t.Errorf("expected changes to be coalesced into one run, got another with %q", strings.TrimSpace(result.Steps[0].Output))
+			case <-time.After(500 * time.Millisecond):
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k3ss-official/k3ss-ai-coder/task-3-cli-automation/internal/automation"
+)
+
+func TestWorkflowYAMLRoundTrip(t *testing.T) {
+	original := &automation.Workflow{
+		Name:        "release",
+		Description: "Build: then ship # carefully",
+		Trigger: automation.WorkflowTrigger{
+			Type:       "git_hook",
+			Events:     []string{"pre-push"},
+			Conditions: []string{"git.branch == 'main'"},
+		},
+		Steps: []automation.WorkflowStep{
+			{
+				Name:        "Build",
+				Command:     "go",
+				Args:        []string{"build", "-ldflags", "-X main.version=1.0: \"rc\""},
+				WorkingDir:  "cmd",
+				Environment: map[string]string{"CGO_ENABLED": "0"},
+			},
+			{Name: "Notify", Command: "echo", Args: []string{"yes", "no", "- dash"}, ContinueOnError: true},
+		},
+		Environment: map[string]string{"GOFLAGS": "-mod=mod", "EMPTY": ""},
+	}
+
+	data, err := automation.MarshalWorkflow(original)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := automation.ParseWorkflow(data, "release.yaml")
+	if err != nil {
+		t.Fatalf("failed to parse marshalled workflow: %v\n%s", err, data)
+	}
+	if !reflect.DeepEqual(original, loaded) {
+		t.Errorf("round trip changed the workflow:\nwant %+v\ngot  %+v", original, loaded)
+	}
+}
+
+func TestLoadWorkflowsReadsSteps(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".k3ss-ai", "workflows", "quality-check.yaml"), `name: quality-check
+description: Run code quality and security checks
+trigger:
+  type: git_hook
+steps:
+  - name: Lint code
+    command: npm
+    args:
+      - run
+      - lint
+  - name: Type check
+    command: npm
+    args: [run, type-check]
+  - name: Security audit
+    command: npm
+    args:
+      - audit
+`)
+
+	service := automation.NewAutomationService(root)
+	if err := service.LoadWorkflows(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	workflow, err := service.GetWorkflow("quality-check")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(workflow.Steps) != 3 || workflow.Steps[1].Args[1] != "type-check" {
+		t.Errorf("expected three steps, got %+v", workflow.Steps)
+	}
+}
+
+func TestWorkflowErrorsIncludeLineNumbers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.yaml")
+	writeFile(t, path, "name: broken\nsteps:\n  - name: ok\n    command: true\n  - name: typo\n    comand: echo\n")
+
+	_, err := automation.LoadWorkflowFile(path)
+	var problems automation.WorkflowErrors
+	if !errors.As(err, &problems) || len(problems) != 1 || problems[0].Line != 6 {
+		t.Fatalf("expected an unknown field error on line 6, got %v", err)
+	}
+
+	writeFile(t, path, "name: broken\ntrigger:\n  type: sometimes\nsteps:\n  - name: empty\n")
+	_, err = automation.LoadWorkflowFile(path)
+	if !errors.As(err, &problems) || len(problems) != 2 || problems[0].Line != 3 || problems[1].Line != 5 {
+		t.Fatalf("expected trigger and command errors on lines 3 and 5, got %v", err)
+	}
+}
+
+func TestWorkflowDAGExecution(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".k3ss-ai", "workflows", "dag.yaml"), `name: dag
+parallelism: 2
+steps:
+  - id: slow-a
+    name: Slow A
+    command: sleep
+    args: ["0.3"]
+  - id: slow-b
+    name: Slow B
+    command: sleep
+    args: ["0.3"]
+  - id: broken
+    name: Broken
+    command: "false"
+    needs: [slow-a]
+  - id: after-broken
+    name: After broken
+    command: "true"
+    needs: [broken]
+  - id: after-b
+    name: After B
+    command: "true"
+    needs: [slow-b]
+`)
+
+	service := automation.NewAutomationService(root)
+	if err := service.LoadWorkflows(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := service.ExecuteWorkflow("dag")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Success {
+		t.Error("expected the workflow to fail")
+	}
+
+	steps := make(map[string]automation.StepResult)
+	for _, step := range result.Steps {
+		steps[step.StepID] = step
+	}
+	if !steps["after-broken"].Skipped {
+		t.Error("expected the dependent of a failed step to be skipped")
+	}
+	if !steps["after-b"].Success {
+		t.Errorf("expected the unrelated branch to succeed, got %v", steps["after-b"].Error)
+	}
+	if gap := steps["slow-b"].StartTime.Sub(steps["slow-a"].StartTime); gap < -200*time.Millisecond || gap > 200*time.Millisecond {
+		t.Errorf("expected independent steps to start together, started %v apart", gap)
+	}
+}
+
+func TestWorkflowCycleDetectedAtLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cycle.yaml")
+	writeFile(t, path, "steps:\n  - id: a\n    command: echo\n    needs: [b]\n  - id: b\n    command: echo\n    needs: [a]\n")
+
+	_, err := automation.LoadWorkflowFile(path)
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle: a -> b -> a") {
+		t.Fatalf("expected a cycle error, got %v", err)
+	}
+}
+
+func TestWorkflowExpressionsAndOutputs(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".k3ss-ai", "workflows", "release.yaml"), `name: release
+environment:
+  CHANNEL: beta
+steps:
+  - id: version
+    name: Compute version
+    command: sh
+    args: ["-c", "echo version=1.2.3 >> $K3SS_OUTPUT"]
+  - id: tag
+    name: Tag
+    command: sh
+    args: ["-c", "echo tag=v$VERSION-${{ env.CHANNEL }} >> $K3SS_OUTPUT"]
+    environment:
+      VERSION: ${{ steps.version.outputs.version }}
+  - id: stable-only
+    name: Stable only
+    command: "false"
+    if: env.CHANNEL == 'stable'
+  - id: last
+    name: Last
+    command: "true"
+`)
+
+	service := automation.NewAutomationService(root)
+	if err := service.LoadWorkflows(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := service.ExecuteWorkflow("release")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("expected the workflow to succeed, got %v", result.Error)
+	}
+
+	if tag := result.Steps[1].Outputs["tag"]; tag != "v1.2.3-beta" {
+		t.Errorf("expected tag output v1.2.3-beta, got %q", tag)
+	}
+	if !result.Steps[2].Skipped || !result.Steps[3].Success {
+		t.Errorf("expected the if: false step to be skipped without blocking later steps, got %+v", result.Steps[2:])
+	}
+}
+
+func TestWorkflowInvalidExpressionsReportedAtLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.yaml")
+	writeFile(t, path, `steps:
+  - id: a
+    command: echo ${{ secrets.token }}
+  - id: b
+    command: echo
+    if: steps.a.result ==
+  - id: c
+    command: echo ${{ steps.d.outputs.x }}
+  - id: d
+    command: echo
+`)
+
+	_, err := automation.LoadWorkflowFile(path)
+	var problems automation.WorkflowErrors
+	if !errors.As(err, &problems) {
+		t.Fatalf("expected workflow errors, got %v", err)
+	}
+
+	lines := make([]int, len(problems))
+	for i, problem := range problems {
+		lines[i] = problem.Line
+	}
+	if !reflect.DeepEqual(lines, []int{3, 6, 8}) {
+		t.Errorf("expected problems on lines 3, 6 and 8, got %v", err)
+	}
+}